@@ -0,0 +1,114 @@
+package govnr
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// A Backoff decides how long Forever waits before re-running f() after a failed run, i.e. one that panicked or, with ForeverCtx, returned an error.
+// attempt is 1 for the first restart after a healthy run, and previous is the delay Backoff returned for the prior attempt (zero for the first).
+// Implementations must be safe for concurrent use, as a single Backoff may be shared by many governed goroutines.
+type Backoff interface {
+	Delay(attempt int, previous time.Duration) time.Duration
+}
+
+// BackoffFunc adapts an ordinary function to the Backoff interface.
+type BackoffFunc func(attempt int, previous time.Duration) time.Duration
+
+func (f BackoffFunc) Delay(attempt int, previous time.Duration) time.Duration {
+	return f(attempt, previous)
+}
+
+// RestartPolicy controls how Forever restarts a function after a failed run.
+type RestartPolicy struct {
+	// Backoff computes the wait between restarts; a nil Backoff restarts immediately
+	Backoff Backoff
+	// HealthyAfter is how long a run must last to be considered healthy; a healthy run resets the Backoff attempt count.
+	// Zero means the attempt count is never reset
+	HealthyAfter time.Duration
+}
+
+// Waits d between every restart
+func ConstantBackoff(d time.Duration) Backoff {
+	return BackoffFunc(func(int, time.Duration) time.Duration {
+		return d
+	})
+}
+
+// Waits initial before the first restart, multiplying the wait by multiplier on every subsequent restart
+func ExponentialBackoff(initial time.Duration, multiplier float64) Backoff {
+	return BackoffFunc(func(attempt int, _ time.Duration) time.Duration {
+		d := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+		if d >= math.MaxInt64 {
+			return time.Duration(math.MaxInt64)
+		}
+		return time.Duration(d)
+	})
+}
+
+// Waits a random duration between base and three times the previous wait, as described in
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+// Usually combined with CappedBackoff, since the wait grows without bound.
+func DecorrelatedJitterBackoff(base time.Duration) Backoff {
+	return BackoffFunc(func(_ int, previous time.Duration) time.Duration {
+		upper := previous * 3
+		if upper <= base { // also covers overflow of previous * 3
+			if previous > base {
+				return previous
+			}
+			return base
+		}
+		return base + time.Duration(rand.Int63n(int64(upper-base)))
+	})
+}
+
+// Limits the wait returned by b to max
+func CappedBackoff(b Backoff, max time.Duration) Backoff {
+	return BackoffFunc(func(attempt int, previous time.Duration) time.Duration {
+		if d := b.Delay(attempt, previous); d < max {
+			return d
+		}
+		return max
+	})
+}
+
+// tracks the restart attempts of a single governed goroutine against its RestartPolicy
+type backoffState struct {
+	policy  RestartPolicy
+	attempt int
+	delay   time.Duration
+}
+
+// must be called after every run of f(), with the duration of the run
+func (s *backoffState) ran(duration time.Duration) {
+	if s.policy.HealthyAfter > 0 && duration >= s.policy.HealthyAfter {
+		s.attempt = 0
+		s.delay = 0
+	}
+}
+
+func (s *backoffState) next() time.Duration {
+	if s.policy.Backoff == nil {
+		return 0
+	}
+	s.attempt++
+	s.delay = s.policy.Backoff.Delay(s.attempt, s.delay)
+	return s.delay
+}
+
+// blocks for d, returning false if ctx was closed before d elapsed
+func sleepUnlessDone(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
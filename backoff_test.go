@@ -0,0 +1,64 @@
+package govnr
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff_GrowsByMultiplier(t *testing.T) {
+	b := ExponentialBackoff(10*time.Millisecond, 2)
+	require.Equal(t, 10*time.Millisecond, b.Delay(1, 0))
+	require.Equal(t, 20*time.Millisecond, b.Delay(2, 0))
+	require.Equal(t, 80*time.Millisecond, b.Delay(4, 0))
+}
+
+func TestCappedBackoff_LimitsDelay(t *testing.T) {
+	b := CappedBackoff(ExponentialBackoff(10*time.Millisecond, 10), 50*time.Millisecond)
+	require.Equal(t, 10*time.Millisecond, b.Delay(1, 0))
+	require.Equal(t, 50*time.Millisecond, b.Delay(2, 0))
+	require.Equal(t, 50*time.Millisecond, b.Delay(100, 0))
+}
+
+func TestDecorrelatedJitterBackoff_StaysWithinBounds(t *testing.T) {
+	base := 10 * time.Millisecond
+	b := DecorrelatedJitterBackoff(base)
+	previous := time.Duration(0)
+	for attempt := 1; attempt < 20; attempt++ {
+		d := b.Delay(attempt, previous)
+		require.True(t, d >= base, "delay %s is below base", d)
+		if previous > base {
+			require.True(t, d <= previous*3, "delay %s is above three times previous delay %s", d, previous)
+		}
+		previous = d
+	}
+}
+
+func TestBackoffState_ResetsAfterHealthyRun(t *testing.T) {
+	s := &backoffState{policy: RestartPolicy{Backoff: ExponentialBackoff(time.Millisecond, 2), HealthyAfter: time.Second}}
+	s.ran(0)
+	require.Equal(t, 1*time.Millisecond, s.next())
+	s.ran(0)
+	require.Equal(t, 2*time.Millisecond, s.next())
+	s.ran(time.Second)
+	require.Equal(t, 1*time.Millisecond, s.next())
+}
+
+func TestForever_BackoffWaitIsCancelledByContext(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h := Forever(ctx, "backing off", logger, func() {
+		panic("foo")
+	}, WithRestartPolicy(RestartPolicy{Backoff: ConstantBackoff(time.Hour)}))
+	h.MarkSupervised()
+
+	<-logger.errors // first panic, now waiting an hour to restart
+	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer shutdownCancel()
+	h.WaitUntilShutdown(shutdownCtx)
+	require.Empty(t, logger.errors, "error was reported on shutdown")
+}
@@ -0,0 +1,56 @@
+package govnr
+
+import "time"
+
+// BusyRestartPolicy protects against a Forever function that keeps returning normally right away, e.g. because the channel it reads from was closed,
+// which would otherwise restart it in a tight loop and pin a CPU core.
+// After Streak consecutive runs that each returned in less than Threshold, a *BusyRestartError is emitted to the Errorer
+// and every further restart is delayed according to Throttle, until a run lasts at least Threshold. A zero Threshold disables detection.
+type BusyRestartPolicy struct {
+	Threshold time.Duration
+	Streak    int
+	Throttle  Backoff
+}
+
+// Used by Forever unless another BusyRestartPolicy is set WithBusyRestartPolicy
+var DefaultBusyRestartPolicy = BusyRestartPolicy{
+	Threshold: time.Millisecond,
+	Streak:    100,
+	Throttle:  CappedBackoff(ExponentialBackoff(10*time.Millisecond, 2), time.Second),
+}
+
+// tracks consecutive busy runs of a single governed goroutine
+type busyDetector struct {
+	policy  BusyRestartPolicy
+	streak  int
+	attempt int
+	delay   time.Duration
+}
+
+// must be called after every run that returned normally, with its duration; returns how long to throttle the next restart,
+// and whether this run is the one that made the streak long enough to be reported
+func (b *busyDetector) returned(duration time.Duration) (throttle time.Duration, report bool) {
+	if b.policy.Threshold <= 0 || duration >= b.policy.Threshold {
+		b.reset()
+		return 0, false
+	}
+	b.streak++
+	minStreak := b.policy.Streak
+	if minStreak < 1 {
+		minStreak = 1
+	}
+	if b.streak < minStreak {
+		return 0, false
+	}
+	if b.policy.Throttle != nil {
+		b.attempt++
+		b.delay = b.policy.Throttle.Delay(b.attempt, b.delay)
+	}
+	return b.delay, b.streak == minStreak
+}
+
+func (b *busyDetector) reset() {
+	b.streak = 0
+	b.attempt = 0
+	b.delay = 0
+}
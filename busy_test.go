@@ -0,0 +1,70 @@
+package govnr
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBusyDetector_ThrottlesAfterStreakAndResetsOnSlowRun(t *testing.T) {
+	b := &busyDetector{policy: BusyRestartPolicy{Threshold: time.Millisecond, Streak: 2, Throttle: ExponentialBackoff(10*time.Millisecond, 2)}}
+
+	throttle, report := b.returned(0)
+	require.Zero(t, throttle)
+	require.False(t, report)
+
+	throttle, report = b.returned(0)
+	require.Equal(t, 10*time.Millisecond, throttle)
+	require.True(t, report, "streak should be reported once it reaches the minimum")
+
+	throttle, report = b.returned(0)
+	require.Equal(t, 20*time.Millisecond, throttle)
+	require.False(t, report, "streak should be reported only once")
+
+	throttle, _ = b.returned(time.Second)
+	require.Zero(t, throttle)
+	require.Zero(t, b.streak)
+}
+
+type restartObserver struct {
+	NopObserver
+	restarts chan Event
+}
+
+func (o *restartObserver) OnRestart(e Event) {
+	o.restarts <- e
+}
+
+func TestForever_ReportsAndThrottlesBusyRestarts(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	observer := &restartObserver{restarts: make(chan Event, 10)}
+
+	runs := make(chan struct{}, 10)
+	h := Forever(ctx, "returns right away", logger, func() {
+		runs <- struct{}{}
+	}, WithBusyRestartPolicy(BusyRestartPolicy{Threshold: time.Hour, Streak: 3, Throttle: ConstantBackoff(time.Hour)}), WithObserver(observer))
+	h.MarkSupervised()
+
+	require.Zero(t, (<-observer.restarts).Duration)
+	require.Zero(t, (<-observer.restarts).Duration)
+	throttled := <-observer.restarts
+	require.Equal(t, 4, throttled.Attempt)
+	require.Equal(t, time.Hour, throttled.Duration, "the third run in a row returning right away should be throttled")
+	require.Len(t, runs, 3)
+	require.Zero(t, h.Throttled(), "restarts that weren't throttled were counted")
+
+	report := <-logger.errors
+	require.True(t, errors.Is(report.err, ErrBusyRestart), "not a busy restart: %v", report.err)
+	var busyErr *BusyRestartError
+	require.True(t, errors.As(report.err, &busyErr))
+	require.Equal(t, 3, busyErr.Runs)
+
+	cancel()
+	h.WaitUntilShutdown(context.Background())
+	require.Len(t, runs, 3, "restarted while throttled")
+	require.NotZero(t, h.Throttled())
+	require.Empty(t, logger.errors, "busy restart should be reported once")
+}
@@ -0,0 +1,52 @@
+package govnr
+
+import "context"
+
+type supervisorKey struct{}
+
+// Returns a copy of ctx carrying sup, so that SupervisedForever, SupervisedForeverCtx and SupervisedGo register the goroutines
+// they start with sup. This lets nested components find their Supervisor through the Context they already receive,
+// instead of having it passed through every constructor. The Context of a TreeSupervisor already carries the TreeSupervisor.
+func WithSupervisor(ctx context.Context, sup Supervisor) context.Context {
+	return context.WithValue(ctx, supervisorKey{}, sup)
+}
+
+// Returns the Supervisor carried by ctx, as set WithSupervisor, and whether ctx carries one
+func SupervisorFrom(ctx context.Context) (Supervisor, bool) {
+	sup, ok := ctx.Value(supervisorKey{}).(Supervisor)
+	return sup, ok
+}
+
+// Like Forever, except that the ForeverHandle is supervised by the Supervisor carried by ctx before f() first runs, see TreeSupervisor.Forever.
+// If ctx doesn't carry a Supervisor, behaves exactly as Forever does. If it carries a TreeSupervisor that has already shut down,
+// f() never runs: the ForeverHandle is returned already terminated, and a *SupervisorShutDownError is emitted to the provided Errorer.
+func SupervisedForever(ctx context.Context, name string, errorHandler Errorer, f func(), opts ...Option) *ForeverHandle {
+	return startForever(ctx, superviseFrom(ctx), name, errorHandler, opts, Permanent, runForever(errorHandler, name, f))
+}
+
+// Like ForeverCtx, except that the ForeverHandle is supervised by the Supervisor carried by ctx before f() first runs, see SupervisedForever
+func SupervisedForeverCtx(ctx context.Context, name string, errorHandler Errorer, f func(ctx context.Context) error, opts ...Option) *ForeverHandle {
+	return startForever(ctx, superviseFrom(ctx), name, errorHandler, opts, Transient, runForeverCtx(errorHandler, name, f))
+}
+
+// Like Go, except that the OnceHandle is supervised by the Supervisor carried by ctx before f() runs, see SupervisedForever.
+// ctx is used only to find the Supervisor and the pprof labels of its goroutines; it isn't passed to f()
+func SupervisedGo(ctx context.Context, name string, errorHandler Errorer, f func(), opts ...Option) *OnceHandle {
+	return startGo(ctx, superviseFrom(ctx), name, errorHandler, f, opts)
+}
+
+// returns how the Supervisor carried by ctx supervises the goroutines started through it, refusing them rather than panicking
+// if it has already shut down; nil if ctx doesn't carry a Supervisor
+func superviseFrom(ctx context.Context) superviseFunc {
+	sup, ok := SupervisorFrom(ctx)
+	if !ok {
+		return nil
+	}
+	if r, ok := sup.(refusingSupervisor); ok {
+		return r.trySupervise
+	}
+	return func(w ShutdownWaiter) bool {
+		sup.Supervise(w)
+		return true
+	}
+}
@@ -0,0 +1,112 @@
+package govnr
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSupervisorFrom_FindsSupervisorSetWithSupervisor(t *testing.T) {
+	_, ok := SupervisorFrom(context.Background())
+	require.False(t, ok)
+
+	s := &TreeSupervisor{}
+	sup, ok := SupervisorFrom(WithSupervisor(context.Background(), s))
+	require.True(t, ok)
+	require.Equal(t, s, sup)
+}
+
+func TestTreeSupervisor_ContextCarriesSupervisor(t *testing.T) {
+	s := NewTreeSupervisor(context.Background(), "node")
+	sup, ok := SupervisorFrom(s.Context())
+	require.True(t, ok)
+	require.Equal(t, s, sup)
+}
+
+type component struct {
+	handles []ShutdownWaiter
+}
+
+// stands for a component nested deep in a node, which only receives a Context
+func newComponent(ctx context.Context, logger Errorer) *component {
+	return &component{handles: []ShutdownWaiter{
+		SupervisedForever(ctx, "forever", logger, func() {
+			<-ctx.Done()
+		}),
+		SupervisedForeverCtx(ctx, "forever ctx", logger, func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}),
+		SupervisedGo(ctx, "go", logger, func() {}),
+	}}
+}
+
+func TestSupervisedForever_RegistersWithSupervisorInContext(t *testing.T) {
+	logger := bufferedLogger()
+	s := NewTreeSupervisor(context.Background(), "node")
+
+	newComponent(s.Context(), logger)
+
+	report := s.Shutdown(context.Background())
+	require.True(t, report.Clean())
+	var names []string
+	for _, c := range report.Children {
+		names = append(names, c.Name)
+	}
+	require.Equal(t, []string{"node/forever", "node/forever ctx", "node/go"}, names)
+	require.Empty(t, logger.errors)
+}
+
+func TestSupervisedForever_WithoutSupervisorInContext_IsUnsupervised(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := newComponent(ctx, logger)
+	for _, h := range c.handles {
+		h.WaitUntilShutdown(context.Background())
+	}
+
+	for range c.handles {
+		report := <-logger.errors
+		require.IsType(t, &UnsupervisedTerminationError{}, report.err)
+	}
+}
+
+func TestSupervisedForever_AfterSupervisorShutDown_ReturnsTerminatedHandle(t *testing.T) {
+	logger := bufferedLogger()
+	s := NewTreeSupervisor(context.Background(), "node")
+	s.Shutdown(context.Background())
+
+	c := newComponent(s.Context(), logger)
+	for _, h := range c.handles {
+		h.WaitUntilShutdown(context.Background())
+	}
+
+	for range c.handles {
+		report := <-logger.errors
+		require.True(t, errors.Is(report.err, ErrSupervisorShutDown), "not a supervisor shut down error: %v", report.err)
+	}
+	require.Empty(t, logger.errors, "a goroutine that never ran was reported again")
+	require.Empty(t, s.Snapshot())
+}
+
+type cancellingSupervisor struct{}
+
+func (cancellingSupervisor) Supervise(w ShutdownWaiter) {
+	h := w.(*ForeverHandle)
+	h.MarkSupervised()
+	h.Cancel()
+}
+
+func TestSupervisedForeverCtx_HandleCanBeCancelledFromSupervise(t *testing.T) {
+	logger := bufferedLogger()
+	ctx := WithSupervisor(context.Background(), cancellingSupervisor{})
+	h := SupervisedForeverCtx(ctx, "foo", logger, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-h.Done()
+	require.Empty(t, logger.errors)
+}
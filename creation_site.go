@@ -0,0 +1,63 @@
+package govnr
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// How much of their caller's stack Forever, ForeverCtx, Go, Once, Recover, NewStrategySupervisor and StartChild record
+// as the creation site of a governed goroutine. The creation site is included in every error reported for the goroutine
+// and in its GoroutineInfo, so that goroutines sharing a name can be traced back to the code that started them
+type CreationSiteCapture int
+
+const (
+	// Records only the function that started the governed goroutine, which is cheap enough to leave on
+	CaptureCaller CreationSiteCapture = iota
+	// Records the whole stack that led to starting the governed goroutine
+	CaptureStack
+	// Records nothing
+	CaptureNothing
+)
+
+const maxCreationSiteDepth = 32
+
+func (c CreationSiteCapture) depth() int {
+	switch c {
+	case CaptureCaller:
+		return 1
+	case CaptureStack:
+		return maxCreationSiteDepth
+	}
+	return 0
+}
+
+// records the creation site as configured by c; skip is the number of frames to skip, 0 identifying the caller of captureCreationSite
+func captureCreationSite(c CreationSiteCapture, skip int) []Frame {
+	depth := c.depth()
+	if depth == 0 {
+		return nil
+	}
+	pc := make([]uintptr, depth)
+	n := runtime.Callers(skip+2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+
+	var site []Frame
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "runtime.goexit" {
+			site = append(site, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		if !more {
+			break
+		}
+	}
+	return site
+}
+
+// describes the creation site at the end of a single line error message, empty if it wasn't recorded
+func createdAt(createdBy []Frame) string {
+	if len(createdBy) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (created at %s:%d)", createdBy[0].File, createdBy[0].Line)
+}
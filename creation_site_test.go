@@ -0,0 +1,87 @@
+package govnr
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"runtime"
+	"testing"
+)
+
+func currentLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+func TestForever_RecordsCallerAsCreationSite(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	line := currentLine() + 1
+	h := Forever(ctx, "foo", logger, func() {})
+	<-h.Done()
+
+	require.Len(t, h.Snapshot()[0].CreatedBy, 1)
+	createdBy := h.Snapshot()[0].CreatedBy[0]
+	require.Equal(t, "github.com/orbs-network/govnr.TestForever_RecordsCallerAsCreationSite", createdBy.Function)
+	require.Equal(t, line, createdBy.Line)
+
+	report := <-logger.errors
+	var unsupervisedErr *UnsupervisedTerminationError
+	require.True(t, errors.As(report.err, &unsupervisedErr))
+	require.Equal(t, []Frame{createdBy}, unsupervisedErr.CreatedBy)
+	require.Contains(t, report.err.Error(), "creation_site_test.go")
+}
+
+func TestOnce_IncludesCreationSiteInPanicError(t *testing.T) {
+	logger := bufferedLogger()
+
+	line := currentLine() + 1
+	Once(logger, localFunctionThatPanics)
+
+	report := <-logger.errors
+	var panicErr *PanicError
+	require.True(t, errors.As(report.err, &panicErr))
+	require.Len(t, panicErr.CreatedBy, 1)
+	require.Equal(t, line, panicErr.CreatedBy[0].Line)
+	require.Contains(t, report.err.Error(), "goroutine created at:\ngithub.com/orbs-network/govnr.TestOnce_IncludesCreationSiteInPanicError")
+}
+
+func TestRecover_CapturesWholeStackWithCaptureStack(t *testing.T) {
+	logger := bufferedLogger()
+
+	Recover(logger, localFunctionThatPanics, WithCreationSiteCapture(CaptureStack))
+
+	report := <-logger.errors
+	var panicErr *PanicError
+	require.True(t, errors.As(report.err, &panicErr))
+	require.True(t, len(panicErr.CreatedBy) > 1, "expected the whole stack, got %v", panicErr.CreatedBy)
+	require.Equal(t, "github.com/orbs-network/govnr.TestRecover_CapturesWholeStackWithCaptureStack", panicErr.CreatedBy[0].Function)
+	require.Equal(t, "testing.tRunner", panicErr.CreatedBy[1].Function)
+}
+
+func TestGo_RecordsNothingWithCaptureNothing(t *testing.T) {
+	logger := bufferedLogger()
+
+	h := Go("one shot", logger, func() {}, WithCreationSiteCapture(CaptureNothing))
+	<-h.Done()
+
+	require.Nil(t, h.Snapshot()[0].CreatedBy)
+	report := <-logger.errors
+	require.EqualError(t, report.err, "Once governed goroutine one shot terminated without being supervised")
+}
+
+func TestStrategySupervisor_RecordsStartChildCallerAsCreationSiteOfChildren(t *testing.T) {
+	logger := bufferedLogger()
+	s := NewStrategySupervisor(context.Background(), "workers", logger, OneForOne)
+
+	line := currentLine() + 1
+	s.StartChild(ChildSpec{Name: "worker", Start: func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}})
+
+	require.Equal(t, line, s.Snapshot()[0].CreatedBy[0].Line)
+	s.Shutdown(context.Background())
+}
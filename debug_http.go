@@ -0,0 +1,108 @@
+package govnr
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Returns an http.Handler, in the spirit of net/http/pprof, that renders the supervision tree under root and the state of every governed goroutine in it.
+// Responds with JSON if the request has a format=json query parameter or accepts application/json, and with a simple HTML page otherwise.
+//
+//	http.Handle("/debug/govnr", govnr.DebugHandler(supervisor))
+func DebugHandler(root Introspector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tree := newDebugTree(root)
+		if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			_ = encoder.Encode(tree)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = debugPage.Execute(w, tree)
+	})
+}
+
+// a supervisor in the tree rendered by DebugHandler
+type debugNode struct {
+	Name       string           `json:"name"`
+	Goroutines []debugGoroutine `json:"goroutines,omitempty"`
+	Children   []*debugNode     `json:"children,omitempty"`
+}
+
+type debugGoroutine struct {
+	Name      string         `json:"name"`
+	State     GoroutineState `json:"state"`
+	Started   time.Time      `json:"started"`
+	Restarts  int            `json:"restarts"`
+	LastErr   string         `json:"lastErr,omitempty"`
+	CreatedAt string         `json:"createdAt,omitempty"`
+}
+
+func newDebugTree(root Introspector) *debugNode {
+	tree := &debugNode{}
+	tree.add(root)
+	return tree
+}
+
+// adds i, supervised by the supervisor of n, to n: as a child node if it is a supervisor, and as goroutines of n otherwise.
+// A supervisor without a name is merged into n, as joinName does for the names of its goroutines
+func (n *debugNode) add(i Introspector) {
+	s, ok := i.(supervisorIntrospector)
+	if !ok {
+		n.addGoroutines(prefixSnapshot(n.Name, i.Snapshot()))
+		return
+	}
+	node := n
+	if name := s.supervisorName(); name != "" {
+		node = &debugNode{Name: joinName(n.Name, name)}
+		n.Children = append(n.Children, node)
+	}
+	goroutines, children := s.introspect()
+	node.addGoroutines(prefixSnapshot(node.Name, goroutines))
+	for _, c := range children {
+		node.add(c)
+	}
+}
+
+func (n *debugNode) addGoroutines(infos []GoroutineInfo) {
+	for _, info := range infos {
+		g := debugGoroutine{Name: info.Name, State: info.State, Started: info.Started, Restarts: info.Restarts}
+		if info.LastErr != nil {
+			g.LastErr = info.LastErr.Error()
+		}
+		if len(info.CreatedBy) > 0 {
+			g.CreatedAt = fmt.Sprintf("%s:%d", info.CreatedBy[0].File, info.CreatedBy[0].Line)
+		}
+		n.Goroutines = append(n.Goroutines, g)
+	}
+}
+
+var debugPage = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>govnr supervision tree</title>
+<style>
+body { font-family: monospace; }
+ul { list-style: none; }
+.running { color: green; }
+.restarting, .backing.off { color: orange; }
+.stopped { color: gray; }
+pre { color: red; margin: 0; }
+</style>
+</head>
+<body>
+<h1>govnr supervision tree</h1>
+{{template "node" .}}
+</body>
+</html>
+{{define "node"}}<ul>
+{{range .Goroutines}}<li><span class="{{.State}}">[{{.State}}]</span> {{.Name}} started {{.Started.Format "2006-01-02T15:04:05Z07:00"}}, restarted {{.Restarts}} times{{if .CreatedAt}}, created at {{.CreatedAt}}{{end}}{{if .LastErr}}<pre>{{.LastErr}}</pre>{{end}}</li>
+{{end}}{{range .Children}}<li><b>{{.Name}}</b>{{template "node" .}}</li>
+{{end}}</ul>{{end}}
+`))
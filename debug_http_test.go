@@ -0,0 +1,98 @@
+package govnr
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func supervisionTreeForDebugging() *TreeSupervisor {
+	logger := bufferedLogger()
+	root := NewTreeSupervisor(context.Background(), "node")
+	ctx := root.Context()
+
+	consensus := &TreeSupervisor{Name: "consensus"}
+	root.Supervise(consensus)
+	consensus.Supervise(Forever(ctx, "sync", logger, func() {
+		<-ctx.Done()
+	}))
+	root.Supervise(Forever(ctx, "gossip", logger, func() {
+		<-ctx.Done()
+	}))
+	return root
+}
+
+func TestDebugHandler_RendersTreeAsJSON(t *testing.T) {
+	root := supervisionTreeForDebugging()
+	defer root.Shutdown(context.Background())
+
+	recorder := httptest.NewRecorder()
+	DebugHandler(root).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/govnr?format=json", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+	var tree struct {
+		Children []struct {
+			Name       string
+			Goroutines []struct{ Name, State string }
+			Children   []struct {
+				Name       string
+				Goroutines []struct{ Name, State string }
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &tree))
+	require.Len(t, tree.Children, 1)
+	node := tree.Children[0]
+	require.Equal(t, "node", node.Name)
+	require.Equal(t, "node/gossip", node.Goroutines[0].Name)
+	require.Equal(t, "running", node.Goroutines[0].State)
+	require.Equal(t, "node/consensus", node.Children[0].Name)
+	require.Equal(t, "node/consensus/sync", node.Children[0].Goroutines[0].Name)
+}
+
+func TestDebugHandler_RendersTreeAsHTML(t *testing.T) {
+	root := supervisionTreeForDebugging()
+	defer root.Shutdown(context.Background())
+
+	recorder := httptest.NewRecorder()
+	DebugHandler(root).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/govnr", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+	require.Contains(t, recorder.Body.String(), "node/consensus/sync")
+	require.Contains(t, recorder.Body.String(), "[running]")
+}
+
+func TestDebugHandler_RendersSupervisorsFromTheTreeRatherThanGoroutineNames(t *testing.T) {
+	root := NewTreeSupervisor(context.Background(), "node")
+	defer root.Shutdown(context.Background())
+	ctx := root.Context()
+
+	root.Supervise(&TreeSupervisor{Name: "idle"})
+	root.Supervise(Forever(ctx, "peers/inbound", bufferedLogger(), func() {
+		<-ctx.Done()
+	}))
+
+	recorder := httptest.NewRecorder()
+	DebugHandler(root).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/govnr?format=json", nil))
+
+	var tree struct {
+		Children []struct {
+			Name       string
+			Goroutines []struct{ Name string }
+			Children   []struct{ Name string }
+		}
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &tree))
+	require.Len(t, tree.Children, 1)
+	node := tree.Children[0]
+	require.Len(t, node.Children, 1)
+	require.Equal(t, "node/idle", node.Children[0].Name)
+	require.Len(t, node.Goroutines, 1)
+	require.Equal(t, "node/peers/inbound", node.Goroutines[0].Name)
+}
@@ -0,0 +1,154 @@
+package govnr
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Sentinels for the conditions govnr reports to an Errorer, to be matched with errors.Is.
+// The errors actually emitted are the typed errors below, which carry the name of the governed goroutine and timing details.
+var (
+	ErrShutdownTimeout         = errors.New("governed goroutine timed out while waiting for shutdown")
+	ErrUnsupervisedTermination = errors.New("governed goroutine terminated without being supervised")
+	ErrCrashLoop               = errors.New("governed goroutine exceeded its restart intensity")
+	ErrBusyRestart             = errors.New("governed goroutine is restarting in a busy loop")
+	ErrSupervisorShutDown      = errors.New("governed goroutine was not started because its supervisor has shut down")
+)
+
+// the kind of handle that emitted an error, as it appears in the error message
+const (
+	kindOnce               = "Once"
+	kindStrategySupervisor = "StrategySupervisor"
+)
+
+func kindOrForever(kind string) string {
+	if kind == "" {
+		return "Forever"
+	}
+	return kind
+}
+
+// Emitted when WaitUntilShutdown returns because its Context timed out before the governed goroutine terminated
+type ShutdownTimeoutError struct {
+	Name string
+	// How long WaitUntilShutdown waited before giving up
+	Waited time.Duration
+	// The error of the Context passed to WaitUntilShutdown
+	Cause error
+	// The stacks of the goroutines still running when WaitUntilShutdown gave up: the governed goroutine,
+	// or the children of a StrategySupervisor, along with any goroutine they started
+	Stacks    []GoroutineStack
+	CreatedBy []Frame
+
+	kind string
+}
+
+func (e *ShutdownTimeoutError) Error() string {
+	msg := fmt.Sprintf("%s governed goroutine %s timed out while waiting for shutdown: %v%s", kindOrForever(e.kind), e.Name, e.Cause, createdAt(e.CreatedBy))
+	for _, s := range e.Stacks {
+		msg += "\n\n" + s.String()
+	}
+	return msg
+}
+
+func (e *ShutdownTimeoutError) Is(target error) bool {
+	return target == ErrShutdownTimeout
+}
+
+func (e *ShutdownTimeoutError) Unwrap() error {
+	return e.Cause
+}
+
+// Emitted when a governed goroutine terminates before it was passed to a Supervisor
+type UnsupervisedTerminationError struct {
+	Name string
+	// How long the governed goroutine ran before terminating
+	Ran       time.Duration
+	CreatedBy []Frame
+
+	kind string
+}
+
+func (e *UnsupervisedTerminationError) Error() string {
+	return fmt.Sprintf("%s governed goroutine %s terminated without being supervised%s", kindOrForever(e.kind), e.Name, createdAt(e.CreatedBy))
+}
+
+func (e *UnsupervisedTerminationError) Is(target error) bool {
+	return target == ErrUnsupervisedTermination
+}
+
+// Emitted when SupervisedForever, SupervisedForeverCtx or SupervisedGo don't start a governed goroutine,
+// because the TreeSupervisor carried by their Context has already shut down
+type SupervisorShutDownError struct {
+	Name      string
+	CreatedBy []Frame
+
+	kind string
+}
+
+func (e *SupervisorShutDownError) Error() string {
+	return fmt.Sprintf("%s governed goroutine %s was not started because its supervisor has shut down%s", kindOrForever(e.kind), e.Name, createdAt(e.CreatedBy))
+}
+
+func (e *SupervisorShutDownError) Is(target error) bool {
+	return target == ErrSupervisorShutDown
+}
+
+// Emitted when a function passed to ForeverCtx, or the Start of a ChildSpec, returns an error
+type RunError struct {
+	Name string
+	// The number of the run of f() that failed, starting at 1
+	Attempt   int
+	Err       error
+	CreatedBy []Frame
+
+	kind string
+}
+
+func (e *RunError) Error() string {
+	return fmt.Sprintf("%s governed goroutine %s failed on run %d: %v%s", kindOrForever(e.kind), e.Name, e.Attempt, e.Err, createdAt(e.CreatedBy))
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// Emitted when a governed goroutine exceeds its RestartIntensity and stops restarting
+type CrashLoopError struct {
+	Name        string
+	MaxRestarts int
+	Period      time.Duration
+	// When the limit was exceeded
+	Time      time.Time
+	CreatedBy []Frame
+
+	kind string
+}
+
+func (e *CrashLoopError) Error() string {
+	return fmt.Sprintf("%s governed goroutine %s restarted more than %d times within %s, giving up%s", kindOrForever(e.kind), e.Name, e.MaxRestarts, e.Period, createdAt(e.CreatedBy))
+}
+
+func (e *CrashLoopError) Is(target error) bool {
+	return target == ErrCrashLoop
+}
+
+// Emitted when a function passed to Forever, or the Start of a ChildSpec, keeps returning right away, see BusyRestartPolicy
+type BusyRestartError struct {
+	Name string
+	// How many consecutive runs returned faster than Threshold
+	Runs      int
+	Threshold time.Duration
+	CreatedBy []Frame
+
+	kind string
+}
+
+func (e *BusyRestartError) Error() string {
+	return fmt.Sprintf("%s governed goroutine %s returned within %s on %d consecutive runs, throttling restarts%s", kindOrForever(e.kind), e.Name, e.Threshold, e.Runs, createdAt(e.CreatedBy))
+}
+
+func (e *BusyRestartError) Is(target error) bool {
+	return target == ErrBusyRestart
+}
@@ -0,0 +1,108 @@
+package govnr
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestForeverHandle_ReportsShutdownTimeoutError(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := Forever(ctx, "stuck", logger, func() {
+		<-ctx.Done()
+	})
+	h.MarkSupervised()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer shutdownCancel()
+	h.WaitUntilShutdown(shutdownCtx)
+
+	report := <-logger.errors
+	require.True(t, errors.Is(report.err, ErrShutdownTimeout), "not a shutdown timeout: %v", report.err)
+	require.True(t, errors.Is(report.err, context.DeadlineExceeded), "doesn't unwrap to the context error")
+	var timeoutErr *ShutdownTimeoutError
+	require.True(t, errors.As(report.err, &timeoutErr))
+	require.Equal(t, "stuck", timeoutErr.Name)
+	require.True(t, timeoutErr.Waited >= 10*time.Millisecond, "waited only %s", timeoutErr.Waited)
+}
+
+func blockOnChildOf(ctx context.Context, started chan struct{}) {
+	go blockOnChannel(ctx)
+	close(started)
+	<-ctx.Done()
+}
+
+func blockOnChannel(ctx context.Context) {
+	<-ctx.Done()
+}
+
+func TestForeverHandle_ShutdownTimeoutErrorIncludesStacksOfHungGoroutineAndItsChildren(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+	h := Forever(ctx, "hung", logger, func() {
+		blockOnChildOf(ctx, started)
+	})
+	h.MarkSupervised()
+	<-started
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer shutdownCancel()
+	h.WaitUntilShutdown(shutdownCtx)
+
+	report := <-logger.errors
+	var timeoutErr *ShutdownTimeoutError
+	require.True(t, errors.As(report.err, &timeoutErr))
+	require.Len(t, timeoutErr.Stacks, 2, "expected the stacks of the goroutine and of its child")
+	require.Contains(t, report.err.Error(), "govnr.blockOnChildOf")
+	require.Contains(t, report.err.Error(), "govnr.blockOnChannel")
+}
+
+func TestStrategySupervisor_ShutdownTimeoutErrorIncludesStacksOfHungChildren(t *testing.T) {
+	logger := bufferedLogger()
+	s := NewStrategySupervisor(context.Background(), "workers", logger, OneForOne)
+	hung, releaseHung := context.WithCancel(context.Background())
+	defer releaseHung()
+	s.StartChild(ChildSpec{Name: "hung", Shutdown: time.Hour, Start: func(ctx context.Context) error {
+		blockOnChannel(hung)
+		return nil
+	}})
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer shutdownCancel()
+	s.Shutdown(shutdownCtx)
+
+	report := <-logger.errors
+	var timeoutErr *ShutdownTimeoutError
+	require.True(t, errors.As(report.err, &timeoutErr))
+	require.Len(t, timeoutErr.Stacks, 1)
+	require.Equal(t, "hung", timeoutErr.Stacks[0].Labels[NameLabel])
+	require.Contains(t, report.err.Error(), "govnr.blockOnChannel")
+}
+
+func TestForeverHandle_ReportsUnsupervisedTerminationError(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	Forever(ctx, "unsupervised", logger, func() {})
+
+	report := <-logger.errors
+	require.True(t, errors.Is(report.err, ErrUnsupervisedTermination), "not an unsupervised termination: %v", report.err)
+	var unsupervisedErr *UnsupervisedTerminationError
+	require.True(t, errors.As(report.err, &unsupervisedErr))
+	require.Equal(t, "unsupervised", unsupervisedErr.Name)
+}
+
+func TestCrashLoopError_MatchesSentinel(t *testing.T) {
+	err := errors.Wrap(&CrashLoopError{Name: "foo"}, "escalated")
+	require.True(t, errors.Is(err, ErrCrashLoop))
+	require.False(t, errors.Is(err, ErrShutdownTimeout))
+}
@@ -3,7 +3,9 @@ package govnr
 import (
 	"context"
 	"github.com/pkg/errors"
+	"runtime/trace"
 	"sync"
+	"time"
 )
 
 type ForeverHandle struct {
@@ -11,17 +13,106 @@ type ForeverHandle struct {
 	closed       chan struct{}
 	errorHandler Errorer
 	name         string
+	started      time.Time
 	supervised   bool
+	intensity    *RestartIntensity
+	ownIntensity bool
+	err          error
+	lastErr      error
+	throttled    time.Duration
+	state        GoroutineState
+	restarts     int
+	id           uint64
+	observers    goroutineObservers
+	createdBy    []Frame
+	ctx          context.Context
+	cancel       context.CancelFunc
 }
 
 func (h *ForeverHandle) WaitUntilShutdown(timeoutCtx context.Context) {
-	select {
-	case <-h.closed:
-	case <-timeoutCtx.Done():
-		if timeoutCtx.Err() == context.DeadlineExceeded {
-			h.errorHandler.Error(errors.Wrapf(timeoutCtx.Err(), "Forever governed goroutine %s timed out while waiting for shutdown", h.name))
-		}
+	h.WaitUntilShutdownWithReport(timeoutCtx)
+}
+
+// Like WaitUntilShutdown, but returns a ShutdownReport with a single entry for this governed goroutine
+func (h *ForeverHandle) WaitUntilShutdownWithReport(timeoutCtx context.Context) *ShutdownReport {
+	return h.observers.ownOnly().shutdown(h.id, h.name, func() *ShutdownReport {
+		return h.waitWithReport(timeoutCtx)
+	})
+}
+
+func (h *ForeverHandle) waitWithReport(timeoutCtx context.Context) *ShutdownReport {
+	waitStarted := time.Now()
+	timedOut := !waitUntilClosed(h.closed, timeoutCtx)
+	if timedOut && timeoutCtx.Err() == context.DeadlineExceeded {
+		h.errorHandler.Error(&ShutdownTimeoutError{Name: h.name, Waited: time.Since(waitStarted), Cause: timeoutCtx.Err(), Stacks: goroutineStacksByID(timeoutCtx, h.id), CreatedBy: h.createdBy})
+	}
+
+	err := h.Err()
+	if err == nil {
+		err = h.LastErr()
 	}
+	return &ShutdownReport{Children: []ChildShutdown{{Name: h.name, Duration: time.Since(waitStarted), TimedOut: timedOut, Err: err}}}
+}
+
+func (h *ForeverHandle) Name() string {
+	return h.name
+}
+
+func (h *ForeverHandle) Snapshot() []GoroutineInfo {
+	h.Lock()
+	defer h.Unlock()
+	lastErr := h.err
+	if lastErr == nil {
+		lastErr = h.lastErr
+	}
+	return []GoroutineInfo{{Name: h.name, State: h.state, Started: h.started, Restarts: h.restarts, LastErr: lastErr, CreatedBy: h.createdBy}}
+}
+
+func (h *ForeverHandle) setState(state GoroutineState) {
+	h.Lock()
+	defer h.Unlock()
+	h.state = state
+}
+
+// waits d before run number attempt, returning false if ctx closed meanwhile. throttled is true if the wait is due to the BusyRestartPolicy
+func (h *ForeverHandle) restart(ctx context.Context, attempt int, d time.Duration, throttled bool) bool {
+	h.observers.observers().restart(h.id, h.name, attempt, d)
+	traceLogf(ctx, "govnr.restart", "attempt %d after %s", attempt, d)
+	h.Lock()
+	h.restarts++
+	h.state = Restarting
+	if d > 0 {
+		h.state = BackingOff
+	}
+	h.Unlock()
+
+	if throttled {
+		started := time.Now()
+		defer func() {
+			h.Lock()
+			defer h.Unlock()
+			h.throttled += time.Since(started)
+		}()
+	}
+	return sleepUnlessDone(ctx, d)
+}
+
+// Returns the Context of this governed goroutine alone, derived from the Context passed to Forever and closed by Cancel or Stop.
+// ForeverCtx passes it to f(), so a function that should stop along with the ForeverHandle should be started with ForeverCtx
+func (h *ForeverHandle) Context() context.Context {
+	return h.ctx
+}
+
+// Closes the Context of this governed goroutine, leaving its siblings running, so that f() is no longer restarted.
+// Doesn't wait for the goroutine to terminate, see Stop
+func (h *ForeverHandle) Cancel() {
+	h.cancel()
+}
+
+// Cancels the governed goroutine, then waits for it to terminate until shutdownContext closes, as WaitUntilShutdownWithReport does
+func (h *ForeverHandle) Stop(shutdownContext context.Context) *ShutdownReport {
+	h.Cancel()
+	return h.WaitUntilShutdownWithReport(shutdownContext)
 }
 
 func (h *ForeverHandle) Done() ContextEndedChan {
@@ -34,30 +125,212 @@ func (h *ForeverHandle) MarkSupervised() {
 	h.supervised = true
 }
 
+// Returns the *CrashLoopError emitted if the governed goroutine exceeded its RestartIntensity and stopped restarting, nil otherwise
+func (h *ForeverHandle) Err() error {
+	h.Lock()
+	defer h.Unlock()
+	return h.err
+}
+
+// Returns the error reported for the most recent failed run of f(), i.e. a *PanicError or a *RunError, nil if no run has failed
+func (h *ForeverHandle) LastErr() error {
+	h.Lock()
+	defer h.Unlock()
+	return h.lastErr
+}
+
+func (h *ForeverHandle) failed(err error) {
+	h.Lock()
+	defer h.Unlock()
+	h.lastErr = err
+}
+
+// Returns the total time restarts were delayed because f() kept returning right away, see BusyRestartPolicy
+func (h *ForeverHandle) Throttled() time.Duration {
+	h.Lock()
+	defer h.Unlock()
+	return h.throttled
+}
+
+func (h *ForeverHandle) inheritRestartIntensity(i RestartIntensity) {
+	h.Lock()
+	defer h.Unlock()
+	if !h.ownIntensity {
+		h.intensity = &i
+	}
+}
+
+func (h *ForeverHandle) inheritObservers(o observers) {
+	h.observers.inherit(o)
+}
+
+func (h *ForeverHandle) restartIntensity() RestartIntensity {
+	h.Lock()
+	defer h.Unlock()
+	if h.intensity == nil {
+		return RestartIntensity{}
+	}
+	return *h.intensity
+}
+
+func (h *ForeverHandle) escalate(limit RestartIntensity) {
+	err := &CrashLoopError{Name: h.name, MaxRestarts: limit.MaxRestarts, Period: limit.Period, Time: time.Now(), CreatedBy: h.createdBy}
+	h.Lock()
+	h.err = err
+	h.Unlock()
+	h.errorHandler.Error(err)
+	if limit.Escalate != nil {
+		limit.Escalate()
+	}
+}
+
 func (h *ForeverHandle) terminated() {
+	h.setState(Stopped)
+	h.observers.observers().terminate(h.id, h.name, h.Err())
 	close(h.closed)
 	h.Lock()
 	defer h.Unlock()
 	if !h.supervised {
-		h.errorHandler.Error(errors.Errorf("Forever governed goroutine %s terminated without being supervised", h.name))
+		h.errorHandler.Error(&UnsupervisedTerminationError{Name: h.name, Ran: time.Since(h.started), CreatedBy: h.createdBy})
 	}
 }
 
+// terminates h without starting its goroutine, because the supervisor it was started under has already shut down
+func (h *ForeverHandle) refused() {
+	h.MarkSupervised() // it never ran, rather than terminated unsupervised
+	h.setState(Stopped)
+	close(h.closed)
+	h.errorHandler.Error(&SupervisorShutDownError{Name: h.name, CreatedBy: h.createdBy})
+}
+
+// supervises h before its goroutine starts, cancelling its Context if supervise refuses it or panics
+func (h *ForeverHandle) supervisedBy(supervise superviseFunc) (accepted bool) {
+	defer func() {
+		if !accepted {
+			h.cancel()
+		}
+	}()
+	return supervise(h)
+}
+
 // Runs f() in a new goroutine; if it panics, emits the error to the provided Errorer.
-// If the provided Context isn't closed, re-runs f().
+// If the provided Context isn't closed, and the ForeverHandle wasn't cancelled with Cancel or Stop, re-runs f(). Restarts after a panic are paced by the RestartPolicy set WithRestartPolicy, if any;
+// the wait between restarts ends early if the Context is closed. If restarts exceed the RestartIntensity set WithRestartIntensity,
+// or inherited from a TreeSupervisor, f() is no longer re-run and the ForeverHandle is marked as failed.
 // Returns a ForeverHandle to allow a Supervisor to wait for graceful shutdown.
 // When f() exists normally, if the ForeverHandle hasn't been passed to a Supervisor, an error will be emitted to the provided Errorer.
-func Forever(ctx context.Context, name string, errorHandler Errorer, f func()) *ForeverHandle {
-	h := &ForeverHandle{closed: make(chan struct{}), name: name, errorHandler: errorHandler}
-	go func() {
-		defer h.terminated()
-
-		for {
-			tryOnce(errorHandler, f)
-			if ctx.Err() != nil { // this returns non-nil when context has been closed via cancellation or timeout or whatever
+// If f() keeps returning normally right away, restarts are throttled according to the BusyRestartPolicy.
+// f() is Permanent unless another RestartType is set WithRestartType.
+// When runtime/trace is enabled, its lifetime is traced as a "govnr.Forever" task and every run of f() as a region,
+// with panics and restarts logged to the task.
+func Forever(ctx context.Context, name string, errorHandler Errorer, f func(), opts ...Option) *ForeverHandle {
+	return startForever(ctx, nil, name, errorHandler, opts, Permanent, runForever(errorHandler, name, f))
+}
+
+func runForever(errorHandler Errorer, name string, f func()) runFunc {
+	return func(ctx context.Context, createdBy []Frame, attempt int) error {
+		if panicErr := tryOnce(errorHandler, name, createdBy, attempt, f); panicErr != nil {
+			return panicErr
+		}
+		return nil
+	}
+}
+
+// Like Forever, but f() receives the Context and returns an error.
+// A non-nil error is emitted to the provided Errorer as a *RunError and f() is restarted, exactly as if it had panicked.
+// When f() returns nil the goroutine terminates, i.e. f() is Transient unless another RestartType is set WithRestartType.
+// Errors returned because the Context was closed are not reported.
+func ForeverCtx(ctx context.Context, name string, errorHandler Errorer, f func(ctx context.Context) error, opts ...Option) *ForeverHandle {
+	return startForever(ctx, nil, name, errorHandler, opts, Transient, runForeverCtx(errorHandler, name, f))
+}
+
+func runForeverCtx(errorHandler Errorer, name string, f func(ctx context.Context) error) runFunc {
+	return func(ctx context.Context, createdBy []Frame, attempt int) error {
+		return tryOnceCtx(ctx, errorHandler, "", name, createdBy, attempt, f)
+	}
+}
+
+// runs f(ctx) once, reporting and returning a *PanicError if it panicked or a *RunError if it returned an error for any reason other than ctx closing
+func tryOnceCtx(ctx context.Context, errorHandler Errorer, kind string, name string, createdBy []Frame, attempt int, f func(ctx context.Context) error) error {
+	var err error
+	if panicErr := tryOnce(errorHandler, name, createdBy, attempt, func() { err = f(ctx) }); panicErr != nil {
+		return panicErr
+	}
+	if err == nil || (ctx.Err() != nil && errors.Is(err, ctx.Err())) {
+		return nil
+	}
+	runErr := &RunError{Name: name, Attempt: attempt, Err: err, CreatedBy: createdBy, kind: kind}
+	errorHandler.Error(runErr)
+	return runErr
+}
+
+// a single run of the governed function, returning the reported error if it failed by panicking or returning an error
+type runFunc func(ctx context.Context, createdBy []Frame, attempt int) error
+
+// starts the governed goroutine; if supervise isn't nil, it first supervises the ForeverHandle, returning it already terminated if refused.
+// Must be called directly by the exported function starting the goroutine, for the creation site to be recorded correctly
+func startForever(ctx context.Context, supervise superviseFunc, name string, errorHandler Errorer, opts []Option, defaultRestartType RestartType, run runFunc) *ForeverHandle {
+	o := newOptions(opts)
+	if o.restartType == nil {
+		o.restartType = &defaultRestartType
+	}
+	h := &ForeverHandle{closed: make(chan struct{}), name: name, started: time.Now(), errorHandler: errorHandler, intensity: o.restartIntensity, ownIntensity: o.restartIntensity != nil, id: nextGoroutineID(), observers: goroutineObservers{own: o.observers, all: o.observers}, createdBy: captureCreationSite(o.creationSite, 2)}
+	h.ctx, h.cancel = context.WithCancel(ctx)
+	if supervise != nil && !h.supervisedBy(supervise) {
+		h.refused()
+		return h
+	}
+	go withLabels(h.ctx, h.id, name, func(ctx context.Context) {
+		h.loop(ctx, o, run)
+	})
+	return h
+}
+
+func (h *ForeverHandle) loop(ctx context.Context, o *options, run runFunc) {
+	defer h.terminated()
+	defer h.cancel()
+	ctx, endTask := traceTask(ctx, "govnr.Forever", h.name)
+	defer endTask()
+
+	backoff := &backoffState{policy: o.restartPolicy}
+	window := &restartWindow{}
+	busy := &busyDetector{policy: o.busyRestart}
+	for attempt := 1; ; attempt++ {
+		h.setState(Running)
+		started := time.Now()
+		err := h.observers.run(h.id, h.name, attempt, func() error {
+			defer trace.StartRegion(ctx, h.name).End()
+			return run(ctx, h.createdBy, attempt)
+		})
+		ran := time.Since(started)
+		backoff.ran(ran)
+		if err != nil {
+			h.failed(err)
+			traceFailed(ctx, attempt, err)
+		}
+		if ctx.Err() != nil { // this returns non-nil when context has been closed via cancellation or timeout or whatever
+			return
+		}
+		if !o.restartType.restarts(err) {
+			return
+		}
+		if err == nil {
+			throttle, report := busy.returned(ran)
+			if report {
+				h.errorHandler.Error(&BusyRestartError{Name: h.name, Runs: busy.streak, Threshold: busy.policy.Threshold, CreatedBy: h.createdBy})
+			}
+			if !h.restart(ctx, attempt+1, throttle, throttle > 0) {
 				return
 			}
+			continue
 		}
-	}()
-	return h
+		busy.reset()
+		if limit := h.restartIntensity(); window.exceeded(limit, time.Now()) {
+			h.escalate(limit)
+			return
+		}
+		if !h.restart(ctx, attempt+1, backoff.next(), false) {
+			return
+		}
+	}
 }
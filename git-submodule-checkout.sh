@@ -1,3 +1,8 @@
 #!/bin/bash
 
-git submodule init && git submodule update --init --recursive
\ No newline at end of file
+git submodule init && git submodule update --init --recursive
+
+# pin the vendored dependencies to the versions required by go.mod
+git -C vendor/github.com/orbs-network/scribe checkout -q v0.2.2
+git -C vendor/github.com/pkg/errors checkout -q v0.9.1
+git -C vendor/github.com/stretchr/testify checkout -q v1.3.0
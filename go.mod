@@ -1,9 +1,9 @@
 module github.com/orbs-network/govnr
 
-go 1.12
+go 1.13
 
 require (
 	github.com/orbs-network/scribe v0.2.2
-	github.com/pkg/errors v0.8.1
+	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.3.0
 )
@@ -45,3 +45,22 @@ func Example() {
 	// goroutine got data: 1
 }
 
+
+func ExampleTreeSupervisor_Shutdown() {
+	errorHandler := &stdoutErrorer{}
+	supervisor := NewTreeSupervisor(context.Background(), "node")
+
+	ctx := supervisor.Context()
+	supervisor.Supervise(Forever(ctx, "an example process", errorHandler, func() {
+		<-ctx.Done()
+	}))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	report := supervisor.Shutdown(shutdownCtx)
+
+	fmt.Printf("%s stopped cleanly: %t\n", report.Children[0].Name, report.Clean())
+
+	// Output:
+	// node/an example process stopped cleanly: true
+}
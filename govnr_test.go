@@ -8,6 +8,7 @@ package govnr
 
 import (
 	"context"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"testing"
 	"time"
@@ -111,3 +112,157 @@ func TestForeverHandle_DoesNotErrorWhenTerminatedAfterSupervision(t *testing.T)
 	h.terminated()
 	require.Empty(t, logger.errors, "error was reported on shutdown")
 }
+
+func TestForeverCtx_ReportsReturnedErrorAndRestarts(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runs := 0
+	h := ForeverCtx(ctx, "erroring service", logger, func(ctx context.Context) error {
+		runs++
+		if runs < 3 {
+			return errors.New("foo")
+		}
+		return nil
+	})
+	h.MarkSupervised()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer shutdownCancel()
+	h.WaitUntilShutdown(shutdownCtx)
+
+	require.Equal(t, 3, runs, "returning nil should end the goroutine")
+	require.Len(t, logger.errors, 2)
+	report := <-logger.errors
+	var runErr *RunError
+	require.True(t, errors.As(report.err, &runErr))
+	require.Equal(t, "erroring service", runErr.Name)
+	require.Equal(t, 1, runErr.Attempt)
+	require.EqualError(t, runErr.Err, "foo")
+}
+
+func TestForeverCtx_ExitsCleanlyWhenContextIsClosed(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h := ForeverCtx(ctx, "another service", logger, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	h.MarkSupervised()
+	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer shutdownCancel()
+	h.WaitUntilShutdown(shutdownCtx)
+	require.Empty(t, logger.errors, "error was reported on shutdown")
+}
+
+func TestGo_ExposesRecoveredPanic(t *testing.T) {
+	logger := bufferedLogger()
+
+	h := Go("one shot", logger, localFunctionThatPanics)
+	h.MarkSupervised()
+	<-h.Done()
+
+	var panicErr *PanicError
+	require.True(t, errors.As(h.Err(), &panicErr), "handle doesn't expose the recovered panic")
+	require.Equal(t, "foo", panicErr.Value)
+	require.Equal(t, "one shot", panicErr.Name)
+}
+
+func TestGo_ErrorsWhenTerminatedWithoutSupervision(t *testing.T) {
+	logger := bufferedLogger()
+
+	h := Go("one shot", logger, func() {})
+	<-h.Done()
+
+	report := <-logger.errors
+	require.Regexp(t, `^Once governed goroutine one shot terminated without being supervised \(created at .*/govnr_test.go:\d+\)$`, report.err.Error())
+	require.True(t, errors.Is(report.err, ErrUnsupervisedTermination))
+	require.NoError(t, h.Err())
+}
+
+func TestGo_CanBeSupervised(t *testing.T) {
+	logger := bufferedLogger()
+	release := make(chan struct{})
+
+	s := &TreeSupervisor{}
+	s.Supervise(Go("one shot", logger, func() {
+		<-release
+	}))
+	close(release)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	s.WaitUntilShutdown(shutdownCtx)
+	require.Empty(t, logger.errors)
+}
+
+func TestForeverHandle_StopCancelsOnlyItsOwnGoroutine(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runs := make(chan struct{}, 10)
+	stopped := ForeverCtx(ctx, "stopped", logger, func(ctx context.Context) error {
+		runs <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithRestartType(Permanent))
+	stopped.MarkSupervised()
+	sibling := ForeverCtx(ctx, "sibling", logger, func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	sibling.MarkSupervised()
+	<-runs
+
+	report := stopped.Stop(context.Background())
+	require.True(t, report.Clean())
+	require.Len(t, runs, 0, "f() was restarted after Stop")
+	require.NoError(t, ctx.Err(), "the parent context was cancelled")
+	select {
+	case <-sibling.Done():
+		t.Fatal("sibling stopped along with the cancelled goroutine")
+	default:
+	}
+
+	cancel()
+	<-sibling.Done()
+	require.Empty(t, logger.errors)
+}
+
+func TestForeverHandle_CancelStopsRestartingAfterCurrentRun(t *testing.T) {
+	logger := bufferedLogger()
+	release := make(chan struct{})
+	h := Forever(context.Background(), "foo", logger, func() {
+		<-release
+	})
+	h.MarkSupervised()
+
+	h.Cancel()
+	close(release)
+	<-h.Done()
+	require.Empty(t, logger.errors)
+}
+
+func TestForeverHandle_StopEndsRunBlockedOnItsContext(t *testing.T) {
+	logger := bufferedLogger()
+	runs := 0
+	h := ForeverCtx(context.Background(), "foo", logger, func(ctx context.Context) error {
+		runs++
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	h.MarkSupervised()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	report := h.Stop(shutdownCtx)
+	require.True(t, report.Clean(), "f() blocked on the ForeverHandle's Context didn't return")
+	require.Equal(t, 1, runs)
+	require.Error(t, h.Context().Err())
+	require.Empty(t, logger.errors)
+}
@@ -0,0 +1,36 @@
+package govnr
+
+import (
+	"time"
+)
+
+// RestartIntensity limits how often a governed goroutine may be restarted after a failed run, similar to the restart intensity of an Erlang supervisor.
+// More than MaxRestarts restarts within Period is considered a crash loop: the goroutine stops restarting, a *CrashLoopError
+// is emitted to its Errorer and, if set, Escalate is called. A zero Period disables the limit.
+type RestartIntensity struct {
+	MaxRestarts int
+	Period      time.Duration
+	// Escalate is called once the limit is exceeded; typically the CancelFunc of a parent context, so that the failure propagates up the supervision tree
+	Escalate func()
+}
+
+func (i RestartIntensity) enabled() bool {
+	return i.Period > 0
+}
+
+// a sliding window of the most recent restarts of a single governed goroutine
+type restartWindow struct {
+	restarts []time.Time
+}
+
+// records a restart at now, returning true if doing so exceeds limit
+func (w *restartWindow) exceeded(limit RestartIntensity, now time.Time) bool {
+	if !limit.enabled() {
+		return false
+	}
+	w.restarts = append(w.restarts, now)
+	for len(w.restarts) > 0 && now.Sub(w.restarts[0]) > limit.Period {
+		w.restarts = w.restarts[1:]
+	}
+	return len(w.restarts) > limit.MaxRestarts
+}
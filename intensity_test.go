@@ -0,0 +1,63 @@
+package govnr
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRestartWindow_ExceedsOnlyWithinPeriod(t *testing.T) {
+	limit := RestartIntensity{MaxRestarts: 2, Period: time.Second}
+	w := &restartWindow{}
+	now := time.Now()
+
+	require.False(t, w.exceeded(limit, now))
+	require.False(t, w.exceeded(limit, now.Add(100*time.Millisecond)))
+	require.False(t, w.exceeded(limit, now.Add(1050*time.Millisecond)), "first restart should have left the window")
+	require.True(t, w.exceeded(limit, now.Add(1090*time.Millisecond)))
+}
+
+func TestForever_EscalatesWhenRestartIntensityExceeded(t *testing.T) {
+	logger := bufferedLogger()
+	parent, cancelParent := context.WithCancel(context.Background())
+	defer cancelParent()
+
+	h := Forever(context.Background(), "crash looping", logger, func() {
+		panic("foo")
+	}, WithRestartIntensity(RestartIntensity{MaxRestarts: 3, Period: time.Minute, Escalate: cancelParent}))
+	h.MarkSupervised()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	h.WaitUntilShutdown(shutdownCtx)
+
+	require.Len(t, logger.errors, 5, "expected 4 panics and a crash loop error")
+	for i := 0; i < 4; i++ {
+		<-logger.errors
+	}
+	report := <-logger.errors
+	require.IsType(t, &CrashLoopError{}, report.err)
+	require.Equal(t, report.err, h.Err())
+	require.Error(t, parent.Err(), "parent context wasn't cancelled on escalation")
+}
+
+func TestTreeSupervisor_AppliesRestartIntensityToSupervisedHandles(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	release := make(chan struct{})
+	s := &TreeSupervisor{RestartIntensity: RestartIntensity{MaxRestarts: 1, Period: time.Minute}}
+	s.Supervise(Forever(ctx, "crash looping", logger, func() {
+		<-release
+		panic("foo")
+	}))
+	close(release)
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer shutdownCancel()
+	s.WaitUntilShutdown(shutdownCtx)
+
+	require.Len(t, logger.errors, 3, "expected 2 panics and a crash loop error")
+}
@@ -0,0 +1,218 @@
+package govnr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Collects metrics about governed goroutines: panics, restarts, currently running and backing off goroutines,
+// and histograms of run durations and of shutdown wait times, all labeled by goroutine name.
+// Attach it to goroutines WithMetrics, and to a TreeSupervisor through its Metrics field to record shutdown wait times
+// and the events of the goroutines it supervises. Metrics is an Observer, so it may also be attached WithObserver.
+//
+// Metrics is an http.Handler serving the Prometheus text exposition format, so it can be mounted next to other Prometheus metrics:
+//
+//	http.Handle("/metrics/govnr", metrics)
+type Metrics struct {
+	mu                sync.Mutex
+	panics            map[string]uint64
+	restarts          map[string]uint64
+	running           map[string]int64
+	backingOff        map[string]int64
+	backingOffIDs     map[uint64]string
+	runDurations      map[string]*histogram
+	shutdownDurations map[string]*histogram
+	buckets           []float64
+}
+
+// Upper bounds, in seconds, of the buckets of the run duration and shutdown wait histograms used by NewMetrics unless others are passed;
+// the same as the Prometheus client defaults
+var DefaultMetricsBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Creates Metrics whose histograms have buckets with the provided ascending upper bounds, in seconds, or DefaultMetricsBuckets if none are provided.
+// The zero value is also usable, with DefaultMetricsBuckets as they are when it first records something
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultMetricsBuckets
+	}
+	return &Metrics{buckets: append([]float64(nil), buckets...)}
+}
+
+// must be called with m.mu held, before recording anything
+func (m *Metrics) init() {
+	if m.panics != nil {
+		return
+	}
+	if len(m.buckets) == 0 {
+		m.buckets = append([]float64(nil), DefaultMetricsBuckets...)
+	}
+	m.panics = make(map[string]uint64)
+	m.restarts = make(map[string]uint64)
+	m.running = make(map[string]int64)
+	m.backingOff = make(map[string]int64)
+	m.backingOffIDs = make(map[uint64]string)
+	m.runDurations = make(map[string]*histogram)
+	m.shutdownDurations = make(map[string]*histogram)
+}
+
+func (m *Metrics) OnStart(e Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+	m.running[e.Name]++
+	m.backedOff(e.ID)
+}
+
+func (m *Metrics) OnPanic(e Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+	m.panics[e.Name]++
+}
+
+func (m *Metrics) OnExit(e Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+	m.running[e.Name]--
+	observe(m.runDurations, m.buckets, e.Name, e.Duration)
+}
+
+func (m *Metrics) OnRestart(e Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+	m.restarts[e.Name]++
+	if e.Duration > 0 {
+		m.backingOff[e.Name]++
+		m.backingOffIDs[e.ID] = e.Name
+	}
+}
+
+func (m *Metrics) OnTerminate(e Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+	m.backedOff(e.ID)
+}
+
+func (m *Metrics) OnShutdownBegin(e Event) {}
+
+func (m *Metrics) OnShutdownTimeout(e Event) {}
+
+func (m *Metrics) OnShutdownEnd(e Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+	observe(m.shutdownDurations, m.buckets, e.Name, e.Duration)
+}
+
+// must be called with m.mu held, when the goroutine with id is no longer waiting to be restarted
+func (m *Metrics) backedOff(id uint64) {
+	if name, ok := m.backingOffIDs[id]; ok {
+		m.backingOff[name]--
+		delete(m.backingOffIDs, id)
+	}
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.write(w)
+}
+
+func (m *Metrics) write(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	b := bufio.NewWriter(w)
+	writeCounter(b, "govnr_panics_total", "Number of panics recovered in governed goroutines.", m.panics)
+	writeCounter(b, "govnr_restarts_total", "Number of times governed goroutines were restarted.", m.restarts)
+	writeGauge(b, "govnr_running", "Number of governed goroutines currently running.", m.running)
+	writeGauge(b, "govnr_backing_off", "Number of governed goroutines currently waiting to be restarted.", m.backingOff)
+	writeHistogram(b, "govnr_run_duration_seconds", "Duration of single runs of governed goroutines.", m.runDurations, m.buckets)
+	writeHistogram(b, "govnr_shutdown_wait_seconds", "Time spent waiting for supervised goroutines to shut down.", m.shutdownDurations, m.buckets)
+	return b.Flush()
+}
+
+type histogram struct {
+	counts []uint64 // per bucket of the Metrics, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func observe(histograms map[string]*histogram, buckets []float64, name string, duration time.Duration) {
+	h, ok := histograms[name]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(buckets))}
+		histograms[name] = h
+	}
+	seconds := duration.Seconds()
+	for i, upperBound := range buckets {
+		if seconds <= upperBound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+func writeCounter(w io.Writer, metric string, help string, values map[string]uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", metric, help, metric)
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	for _, name := range sorted(names) {
+		fmt.Fprintf(w, "%s{name=\"%s\"} %d\n", metric, escapeLabel(name), values[name])
+	}
+}
+
+func writeGauge(w io.Writer, metric string, help string, values map[string]int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", metric, help, metric)
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	for _, name := range sorted(names) {
+		fmt.Fprintf(w, "%s{name=\"%s\"} %d\n", metric, escapeLabel(name), values[name])
+	}
+}
+
+func writeHistogram(w io.Writer, metric string, help string, histograms map[string]*histogram, buckets []float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", metric, help, metric)
+	var names []string
+	for name := range histograms {
+		names = append(names, name)
+	}
+	for _, name := range sorted(names) {
+		h := histograms[name]
+		label := escapeLabel(name)
+		var cumulative uint64
+		for i, upperBound := range buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "%s_bucket{name=\"%s\",le=\"%g\"} %d\n", metric, label, upperBound, cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{name=\"%s\",le=\"+Inf\"} %d\n", metric, label, h.count)
+		fmt.Fprintf(w, "%s_sum{name=\"%s\"} %g\n", metric, label, h.sum)
+		fmt.Fprintf(w, "%s_count{name=\"%s\"} %d\n", metric, label, h.count)
+	}
+}
+
+func sorted(names []string) []string {
+	sort.Strings(names)
+	return names
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
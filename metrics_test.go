@@ -0,0 +1,136 @@
+package govnr
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	return recorder.Body.String()
+}
+
+func TestMetrics_CountsPanicsRestartsAndRuns(t *testing.T) {
+	logger := bufferedLogger()
+	m := NewMetrics()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runs := 0
+	h := Forever(ctx, "flaky", logger, func() {
+		runs++
+		if runs == 3 {
+			cancel()
+		}
+		panic("foo")
+	}, WithMetrics(m))
+	h.MarkSupervised()
+	h.WaitUntilShutdown(context.Background())
+
+	Recover(logger, localFunctionThatPanics, WithMetrics(m))
+
+	out := scrape(t, m)
+	require.Contains(t, out, "# TYPE govnr_panics_total counter\n")
+	require.Contains(t, out, `govnr_panics_total{name="flaky"} 3`)
+	require.Contains(t, out, `govnr_panics_total{name=""} 1`)
+	require.Contains(t, out, `govnr_restarts_total{name="flaky"} 2`)
+	require.Contains(t, out, `govnr_running{name="flaky"} 0`)
+	require.Contains(t, out, `govnr_run_duration_seconds_bucket{name="flaky",le="+Inf"} 3`)
+	require.Contains(t, out, `govnr_run_duration_seconds_count{name="flaky"} 3`)
+}
+
+func TestMetrics_TracksRunningAndBackingOffGauges(t *testing.T) {
+	logger := bufferedLogger()
+	m := NewMetrics()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	running := make(chan struct{})
+	Forever(ctx, "blocking", logger, func() {
+		close(running)
+		<-ctx.Done()
+	}, WithMetrics(m)).MarkSupervised()
+	Forever(ctx, "backing off", logger, func() {
+		panic("foo")
+	}, WithMetrics(m), WithRestartPolicy(RestartPolicy{Backoff: ConstantBackoff(time.Hour)})).MarkSupervised()
+
+	<-running
+	<-logger.errors
+	time.Sleep(10 * time.Millisecond) // let backing off start
+
+	out := scrape(t, m)
+	require.Contains(t, out, `govnr_running{name="blocking"} 1`)
+	require.Contains(t, out, `govnr_backing_off{name="backing off"} 1`)
+}
+
+func TestTreeSupervisor_RecordsShutdownWaitTimes(t *testing.T) {
+	logger := bufferedLogger()
+	m := NewMetrics()
+	s := NewTreeSupervisor(context.Background(), "node")
+	s.Metrics = m
+	ctx := s.Context()
+	s.Supervise(Forever(ctx, "quote\"d", logger, func() {
+		<-ctx.Done()
+	}))
+	s.Shutdown(context.Background())
+
+	require.Contains(t, scrape(t, m), `govnr_shutdown_wait_seconds_count{name="node/quote\"d"} 1`)
+}
+
+func TestMetrics_UsesBucketsItWasCreatedWith(t *testing.T) {
+	m := NewMetrics(0.5, 1)
+	m.OnExit(Event{Name: "foo", Duration: 700 * time.Millisecond})
+
+	defaults := DefaultMetricsBuckets
+	defer func() { DefaultMetricsBuckets = defaults }()
+	DefaultMetricsBuckets = append(DefaultMetricsBuckets, 30)
+	defaultMetrics := NewMetrics()
+	defaultMetrics.OnExit(Event{Name: "foo", Duration: time.Second})
+	DefaultMetricsBuckets = append(DefaultMetricsBuckets, 60)
+
+	out := scrape(t, m)
+	require.Contains(t, out, `govnr_run_duration_seconds_bucket{name="foo",le="0.5"} 0`)
+	require.Contains(t, out, `govnr_run_duration_seconds_bucket{name="foo",le="1"} 1`)
+	require.NotContains(t, out, `le="0.005"`)
+	require.Contains(t, scrape(t, defaultMetrics), `govnr_run_duration_seconds_bucket{name="foo",le="30"} 1`)
+}
+
+func TestMetrics_ZeroValueIsUsable(t *testing.T) {
+	logger := bufferedLogger()
+	m := &Metrics{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h := Forever(ctx, "foo", logger, func() {
+		cancel()
+		panic("foo")
+	}, WithMetrics(m))
+	h.MarkSupervised()
+	<-h.Done()
+
+	require.Contains(t, scrape(t, m), `govnr_panics_total{name="foo"} 1`)
+}
+
+func TestMetrics_CountsRunStartedBeforeSupervisorAttachedIt(t *testing.T) {
+	logger := bufferedLogger()
+	m := NewMetrics()
+	s := NewTreeSupervisor(context.Background(), "node")
+	s.Metrics = m
+
+	ctx := s.Context()
+	started := make(chan struct{})
+	h := Forever(ctx, "foo", logger, func() {
+		close(started)
+		<-ctx.Done()
+	})
+	<-started
+	s.Supervise(h)
+	require.Contains(t, scrape(t, m), `govnr_running{name="foo"} 1`)
+
+	s.Shutdown(context.Background())
+	require.Contains(t, scrape(t, m), `govnr_running{name="foo"} 0`)
+}
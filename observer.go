@@ -0,0 +1,233 @@
+package govnr
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Observes the lifecycle of governed goroutines, e.g. to layer metrics, tracing or audit logging on top of govnr.
+// Attach an Observer to a single goroutine WithObserver, or to a TreeSupervisor through its Observer field to observe the goroutines it supervises and its shutdown.
+// Callbacks are invoked synchronously from the governed goroutine or from the supervisor, so they must not block.
+// Embed NopObserver to implement only some of the callbacks.
+type Observer interface {
+	// A run of f() is starting; Attempt is the number of the run, starting at 1
+	OnStart(e Event)
+	// A run of f() panicked; Err is the *PanicError. Followed by OnExit
+	OnPanic(e Event)
+	// A run of f() ended; Duration is how long it ran and Err is the error reported for it, if any
+	OnExit(e Event)
+	// f() is about to be restarted after waiting Duration
+	OnRestart(e Event)
+	// The governed goroutine terminated and won't run f() again; Err is the error it stopped on, if any
+	OnTerminate(e Event)
+	// A supervisor started waiting for its children to shut down, or WaitUntilShutdown started waiting for the governed goroutine;
+	// Name is the supervisor's or the goroutine's
+	OnShutdownBegin(e Event)
+	// A supervised child, or the governed goroutine, was still running when the shutdown Context closed; Name is the child's hierarchical name
+	OnShutdownTimeout(e Event)
+	// The wait for a supervised child, or for the governed goroutine, ended; Duration is how long it waited and Err the last error
+	OnShutdownEnd(e Event)
+}
+
+// Describes a lifecycle event of a governed goroutine; fields that don't apply to a callback are left zero
+type Event struct {
+	// Uniquely identifies the governed goroutine within the process; zero for events of supervisors
+	ID       uint64
+	Name     string
+	Attempt  int
+	Time     time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// An Observer that does nothing, to be embedded by Observers interested only in some callbacks
+type NopObserver struct{}
+
+func (NopObserver) OnStart(Event)           {}
+func (NopObserver) OnPanic(Event)           {}
+func (NopObserver) OnExit(Event)            {}
+func (NopObserver) OnRestart(Event)         {}
+func (NopObserver) OnTerminate(Event)       {}
+func (NopObserver) OnShutdownBegin(Event)   {}
+func (NopObserver) OnShutdownTimeout(Event) {}
+func (NopObserver) OnShutdownEnd(Event)     {}
+
+var lastGoroutineID uint64
+
+func nextGoroutineID() uint64 {
+	return atomic.AddUint64(&lastGoroutineID, 1)
+}
+
+// fans events out to any number of Observers; a nil observers observes nothing
+type observers []Observer
+
+// returns o along with those of inherited it doesn't already contain, so that an Observer attached both WithObserver
+// and to a supervisor isn't notified twice
+func (o observers) with(inherited observers) observers {
+	merged := append(observers(nil), o...)
+	for _, candidate := range inherited {
+		if candidate != nil && !merged.contains(candidate) {
+			merged = append(merged, candidate)
+		}
+	}
+	return merged
+}
+
+func (o observers) contains(observer Observer) bool {
+	if observer == nil || !reflect.TypeOf(observer).Comparable() {
+		return false
+	}
+	for _, existing := range o {
+		if reflect.TypeOf(existing) == reflect.TypeOf(observer) && existing == observer {
+			return true
+		}
+	}
+	return false
+}
+
+func (o observers) event(id uint64, name string) Event {
+	return Event{ID: id, Name: name, Time: time.Now()}
+}
+
+func (o observers) each(f func(Observer)) {
+	for _, observer := range o {
+		f(observer)
+	}
+}
+
+// runs f() once as tryOnce does, notifying OnStart, OnPanic and OnExit
+func (o observers) tryOnce(id uint64, errorHandler Errorer, name string, createdBy []Frame, attempt int, f func()) (panicErr *PanicError) {
+	o.run(id, name, attempt, func() error {
+		panicErr = tryOnce(errorHandler, name, createdBy, attempt, f)
+		return panicErr.asError()
+	})
+	return
+}
+
+// runs a single run of a governed function, which returns the error reported for it, notifying OnStart, OnPanic and OnExit
+func (o observers) run(id uint64, name string, attempt int, run func() error) error {
+	if len(o) == 0 {
+		return run()
+	}
+	e := o.event(id, name)
+	e.Attempt = attempt
+	o.started(e)
+	err := run()
+	o.exited(e, err)
+	return err
+}
+
+func (o observers) started(e Event) {
+	o.each(func(observer Observer) { observer.OnStart(e) })
+}
+
+// notifies the end of the run that started with the Event start
+func (o observers) exited(start Event, err error) {
+	e := start
+	e.Duration = time.Since(start.Time)
+	e.Time = time.Now()
+	e.Err = err
+	if _, panicked := err.(*PanicError); panicked {
+		o.each(func(observer Observer) { observer.OnPanic(e) })
+	}
+	o.each(func(observer Observer) { observer.OnExit(e) })
+}
+
+func (o observers) restart(id uint64, name string, attempt int, delay time.Duration) {
+	e := o.event(id, name)
+	e.Attempt = attempt
+	e.Duration = delay
+	o.each(func(observer Observer) { observer.OnRestart(e) })
+}
+
+func (o observers) terminate(id uint64, name string, err error) {
+	e := o.event(id, name)
+	e.Err = err
+	o.each(func(observer Observer) { observer.OnTerminate(e) })
+}
+
+// notifies the events of waiting for the children of a supervisor named name, or for the governed goroutine identified by id,
+// to shut down, as described by the report returned by wait
+func (o observers) shutdown(id uint64, name string, wait func() *ShutdownReport) *ShutdownReport {
+	if len(o) == 0 {
+		return wait()
+	}
+	begin := o.event(id, name)
+	o.each(func(observer Observer) { observer.OnShutdownBegin(begin) })
+
+	report := wait()
+
+	for _, c := range report.Children {
+		e := o.event(id, c.Name)
+		e.Duration = c.Duration
+		e.Err = c.Err
+		if c.TimedOut {
+			o.each(func(observer Observer) { observer.OnShutdownTimeout(e) })
+		}
+		o.each(func(observer Observer) { observer.OnShutdownEnd(e) })
+	}
+	return report
+}
+
+// the observers of a governed goroutine: those attached WithObserver, along with those inherited from its supervisor.
+// Observers inherited during a run of f() are notified of its start when inherited, so that they see its end consistently
+type goroutineObservers struct {
+	sync.Mutex
+	own       observers
+	inherited observers
+	all       observers
+	current   Event // the start of the current run of f(), if inRun
+	inRun     bool
+}
+
+func (g *goroutineObservers) inherit(o observers) {
+	g.Lock()
+	defer g.Unlock()
+	all := g.all.with(o)
+	if g.inRun {
+		all[len(g.all):].started(g.current)
+	}
+	g.inherited = g.inherited.with(o)
+	g.all = all
+}
+
+func (g *goroutineObservers) observers() observers {
+	g.Lock()
+	defer g.Unlock()
+	return g.all
+}
+
+// returns the observers attached WithObserver that aren't also inherited, which a supervisor doesn't already notify of shutdown events
+func (g *goroutineObservers) ownOnly() observers {
+	g.Lock()
+	defer g.Unlock()
+	var o observers
+	for _, observer := range g.own {
+		if observer != nil && !g.inherited.contains(observer) {
+			o = append(o, observer)
+		}
+	}
+	return o
+}
+
+// as observers.run does, notifying the observers attached when each event happens
+func (g *goroutineObservers) run(id uint64, name string, attempt int, run func() error) error {
+	g.Lock()
+	start := g.all.event(id, name)
+	start.Attempt = attempt
+	g.current, g.inRun = start, true
+	o := g.all
+	g.Unlock()
+	o.started(start)
+
+	err := run()
+
+	g.Lock()
+	g.inRun = false
+	o = g.all
+	g.Unlock()
+	o.exited(start, err)
+	return err
+}
@@ -0,0 +1,174 @@
+package govnr
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingObserver struct {
+	sync.Mutex
+	events []string
+}
+
+func (o *recordingObserver) record(callback string, e Event) {
+	o.Lock()
+	defer o.Unlock()
+	o.events = append(o.events, fmt.Sprintf("%s %s %d", callback, e.Name, e.Attempt))
+}
+
+func (o *recordingObserver) recorded() []string {
+	o.Lock()
+	defer o.Unlock()
+	return append([]string(nil), o.events...)
+}
+
+func (o *recordingObserver) recordedWithPrefix(prefix string) []string {
+	var events []string
+	for _, e := range o.recorded() {
+		if strings.HasPrefix(e, prefix) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func (o *recordingObserver) recordedWithout(prefix string) []string {
+	var events []string
+	for _, e := range o.recorded() {
+		if !strings.HasPrefix(e, prefix) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func (o *recordingObserver) OnStart(e Event)           { o.record("start", e) }
+func (o *recordingObserver) OnPanic(e Event)           { o.record("panic", e) }
+func (o *recordingObserver) OnExit(e Event)            { o.record("exit", e) }
+func (o *recordingObserver) OnRestart(e Event)         { o.record("restart", e) }
+func (o *recordingObserver) OnTerminate(e Event)       { o.record("terminate", e) }
+func (o *recordingObserver) OnShutdownBegin(e Event)   { o.record("shutdown begin", e) }
+func (o *recordingObserver) OnShutdownTimeout(e Event) { o.record("shutdown timeout", e) }
+func (o *recordingObserver) OnShutdownEnd(e Event)     { o.record("shutdown end", e) }
+
+func TestForever_NotifiesObserverOfLifecycle(t *testing.T) {
+	logger := bufferedLogger()
+	observer := &recordingObserver{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runs := 0
+	h := Forever(ctx, "foo", logger, func() {
+		runs++
+		if runs == 2 {
+			cancel()
+		}
+		panic("foo")
+	}, WithObserver(observer))
+	h.MarkSupervised()
+	h.WaitUntilShutdown(context.Background())
+
+	require.Equal(t, []string{
+		"start foo 1",
+		"panic foo 1",
+		"exit foo 1",
+		"restart foo 2",
+		"start foo 2",
+		"panic foo 2",
+		"exit foo 2",
+		"terminate foo 0",
+	}, observer.recordedWithout("shutdown"))
+}
+
+func TestForeverHandle_NotifiesObserverOfShutdownTimeout(t *testing.T) {
+	logger := bufferedLogger()
+	observer := &recordingObserver{}
+	stuck, releaseStuck := context.WithCancel(context.Background())
+	defer releaseStuck()
+
+	h := Forever(stuck, "stuck", logger, func() {
+		<-stuck.Done()
+	}, WithObserver(observer))
+	h.MarkSupervised()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	h.WaitUntilShutdown(shutdownCtx)
+
+	require.Equal(t, []string{
+		"shutdown begin stuck 0",
+		"shutdown timeout stuck 0",
+		"shutdown end stuck 0",
+	}, observer.recordedWithPrefix("shutdown"))
+}
+
+func TestForever_IgnoresNilObserver(t *testing.T) {
+	observer := &recordingObserver{}
+	s := &TreeSupervisor{Observer: observer}
+	h := s.Go("foo", bufferedLogger(), func() {}, WithObserver(nil))
+	<-h.Done()
+	s.Shutdown(context.Background())
+	require.Contains(t, observer.recorded(), "terminate foo 0")
+}
+
+func TestTreeSupervisor_NotifiesObserverOfShutdown(t *testing.T) {
+	logger := bufferedLogger()
+	observer := &recordingObserver{}
+	s := NewTreeSupervisor(context.Background(), "node")
+	s.Observer = observer
+
+	ctx := s.Context()
+	s.Supervise(Forever(ctx, "clean", logger, func() {
+		<-ctx.Done()
+	}))
+	stuck, releaseStuck := context.WithCancel(context.Background())
+	defer releaseStuck()
+	s.Supervise(Forever(stuck, "stuck", logger, func() {
+		<-stuck.Done()
+	}))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	s.Shutdown(shutdownCtx)
+
+	require.Equal(t, []string{
+		"shutdown begin node 0",
+		"shutdown end node/clean 0",
+		"shutdown timeout node/stuck 0",
+		"shutdown end node/stuck 0",
+	}, observer.recordedWithPrefix("shutdown"))
+}
+
+func TestTreeSupervisor_PassesObserverOnToSupervisedGoroutines(t *testing.T) {
+	logger := bufferedLogger()
+	observer := &recordingObserver{}
+	s := NewTreeSupervisor(context.Background(), "node")
+	s.Observer = observer
+
+	ctx := s.Context()
+	s.Forever("forever", logger, func() {
+		<-ctx.Done()
+	}, WithObserver(observer))
+	done := make(chan struct{})
+	s.Go("once", logger, func() {
+		close(done)
+	})
+	<-done
+
+	s.Shutdown(context.Background())
+
+	events := observer.recorded()
+	require.Contains(t, events, "start forever 1")
+	require.Contains(t, events, "terminate forever 0")
+	require.Contains(t, events, "start once 1")
+	require.Contains(t, events, "terminate once 0")
+	require.Len(t, observer.recordedWithPrefix("start forever"), 1, "an Observer attached twice should be notified once")
+}
+
+func TestNopObserver_CanBeEmbedded(t *testing.T) {
+	var observer Observer = struct{ NopObserver }{}
+	Recover(bufferedLogger(), localFunctionThatPanics, WithObserver(observer))
+}
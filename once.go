@@ -6,15 +6,138 @@
 
 package govnr
 
+import (
+	"context"
+	"sync"
+	"time"
+)
+
 type Errorer interface {
 	Error(err error)
 }
 
 type ContextEndedChan <-chan struct{}
 
-// Runs f() in a new goroutine; if it panics, logs the error and stack trace to the specified Errorer
-func Once(errorHandler Errorer, f func()) {
+// Runs f() in a new goroutine; if it panics, logs the error and stack trace to the specified Errorer.
+// Since it has no name, the goroutine keeps the pprof labels of the goroutine that called Once
+func Once(errorHandler Errorer, f func(), opts ...Option) {
+	o := newOptions(opts)
+	id := nextGoroutineID()
+	createdBy := captureCreationSite(o.creationSite, 1)
 	go func() {
-		tryOnce(errorHandler, f)
+		o.observers.terminate(id, "", o.observers.tryOnce(id, errorHandler, "", createdBy, 1, f).asError())
 	}()
 }
+
+type OnceHandle struct {
+	sync.Mutex
+	closed       chan struct{}
+	errorHandler Errorer
+	name         string
+	started      time.Time
+	supervised   bool
+	panicErr     *PanicError
+	id           uint64
+	observers    goroutineObservers
+	createdBy    []Frame
+}
+
+func (h *OnceHandle) WaitUntilShutdown(timeoutCtx context.Context) {
+	h.WaitUntilShutdownWithReport(timeoutCtx)
+}
+
+// Like WaitUntilShutdown, but returns a ShutdownReport with a single entry for this governed goroutine
+func (h *OnceHandle) WaitUntilShutdownWithReport(timeoutCtx context.Context) *ShutdownReport {
+	return h.observers.ownOnly().shutdown(h.id, h.name, func() *ShutdownReport {
+		return h.waitWithReport(timeoutCtx)
+	})
+}
+
+func (h *OnceHandle) waitWithReport(timeoutCtx context.Context) *ShutdownReport {
+	waitStarted := time.Now()
+	timedOut := !waitUntilClosed(h.closed, timeoutCtx)
+	if timedOut && timeoutCtx.Err() == context.DeadlineExceeded {
+		h.errorHandler.Error(&ShutdownTimeoutError{Name: h.name, Waited: time.Since(waitStarted), Cause: timeoutCtx.Err(), Stacks: goroutineStacksByID(timeoutCtx, h.id), CreatedBy: h.createdBy, kind: kindOnce})
+	}
+	return &ShutdownReport{Children: []ChildShutdown{{Name: h.name, Duration: time.Since(waitStarted), TimedOut: timedOut, Err: h.Err()}}}
+}
+
+func (h *OnceHandle) Name() string {
+	return h.name
+}
+
+func (h *OnceHandle) Snapshot() []GoroutineInfo {
+	state := Running
+	select {
+	case <-h.closed:
+		state = Stopped
+	default:
+	}
+	return []GoroutineInfo{{Name: h.name, State: state, Started: h.started, LastErr: h.Err(), CreatedBy: h.createdBy}}
+}
+
+func (h *OnceHandle) Done() ContextEndedChan {
+	return h.closed
+}
+
+func (h *OnceHandle) MarkSupervised() {
+	h.Lock()
+	defer h.Unlock()
+	h.supervised = true
+}
+
+// Returns the *PanicError reported if f() panicked, nil otherwise. Only meaningful once Done() is closed
+func (h *OnceHandle) Err() error {
+	h.Lock()
+	defer h.Unlock()
+	return h.panicErr.asError()
+}
+
+func (h *OnceHandle) inheritObservers(o observers) {
+	h.observers.inherit(o)
+}
+
+func (h *OnceHandle) terminated(panicErr *PanicError) {
+	h.Lock()
+	h.panicErr = panicErr
+	supervised := h.supervised
+	h.Unlock()
+	h.observers.observers().terminate(h.id, h.name, panicErr.asError())
+	close(h.closed)
+	if !supervised {
+		h.errorHandler.Error(&UnsupervisedTerminationError{Name: h.name, Ran: time.Since(h.started), CreatedBy: h.createdBy, kind: kindOnce})
+	}
+}
+
+// terminates h without starting its goroutine, as ForeverHandle.refused does
+func (h *OnceHandle) refused() {
+	h.MarkSupervised() // it never ran, rather than terminated unsupervised
+	close(h.closed)
+	h.errorHandler.Error(&SupervisorShutDownError{Name: h.name, CreatedBy: h.createdBy, kind: kindOnce})
+}
+
+// Like Once, but returns a OnceHandle so that the goroutine can be passed to a Supervisor and waited on during graceful shutdown.
+// When f() returns, if the OnceHandle hasn't been passed to a Supervisor, an error will be emitted to the provided Errorer.
+func Go(name string, errorHandler Errorer, f func(), opts ...Option) *OnceHandle {
+	return startGo(context.Background(), nil, name, errorHandler, f, opts)
+}
+
+// starts the governed goroutine with the pprof labels of ctx; if supervise isn't nil, it first supervises the OnceHandle,
+// returning it already terminated if refused, as startForever does
+func startGo(ctx context.Context, supervise superviseFunc, name string, errorHandler Errorer, f func(), opts []Option) *OnceHandle {
+	o := newOptions(opts)
+	h := &OnceHandle{closed: make(chan struct{}), name: name, started: time.Now(), errorHandler: errorHandler, id: nextGoroutineID(), observers: goroutineObservers{own: o.observers, all: o.observers}, createdBy: captureCreationSite(o.creationSite, 2)}
+	if supervise != nil && !supervise(h) {
+		h.refused()
+		return h
+	}
+	go withLabels(ctx, h.id, name, func(context.Context) {
+		var panicErr *PanicError
+		h.observers.run(h.id, name, 1, func() error {
+			panicErr = tryOnce(errorHandler, name, h.createdBy, 1, f)
+			return panicErr.asError()
+		})
+		h.terminated(panicErr)
+	})
+	return h
+}
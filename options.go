@@ -0,0 +1,73 @@
+package govnr
+
+// An Option configures the behavior of a governed goroutine
+type Option func(*options)
+
+type options struct {
+	restartPolicy    RestartPolicy
+	restartIntensity *RestartIntensity
+	busyRestart      BusyRestartPolicy
+	restartType      *RestartType
+	observers        observers
+	creationSite     CreationSiteCapture
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{busyRestart: DefaultBusyRestartPolicy}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Sets the RestartPolicy Forever uses to pace restarts after a failed run
+func WithRestartPolicy(p RestartPolicy) Option {
+	return func(o *options) {
+		o.restartPolicy = p
+	}
+}
+
+// Sets the RestartIntensity Forever enforces on restarts after a failed run.
+// Takes precedence over the RestartIntensity of the TreeSupervisor the ForeverHandle is passed to
+func WithRestartIntensity(i RestartIntensity) Option {
+	return func(o *options) {
+		o.restartIntensity = &i
+	}
+}
+
+// Sets the BusyRestartPolicy Forever uses to detect and throttle a function that keeps returning right away
+func WithBusyRestartPolicy(p BusyRestartPolicy) Option {
+	return func(o *options) {
+		o.busyRestart = p
+	}
+}
+
+// Sets whether Forever and ForeverCtx restart f() after it exits, overriding their default RestartType
+func WithRestartType(t RestartType) Option {
+	return func(o *options) {
+		o.restartType = &t
+	}
+}
+
+// Notifies observer of lifecycle events of the governed goroutine; may be used more than once to attach several Observers.
+// A nil observer is ignored
+func WithObserver(observer Observer) Option {
+	return func(o *options) {
+		if observer == nil {
+			return
+		}
+		o.observers = append(o.observers, observer)
+	}
+}
+
+// Records runs, panics, restarts and backoffs in m; equivalent to WithObserver(m)
+func WithMetrics(m *Metrics) Option {
+	return WithObserver(m)
+}
+
+// Sets how much of the caller's stack is recorded as the creation site of the governed goroutine; CaptureCaller by default
+func WithCreationSiteCapture(c CreationSiteCapture) Option {
+	return func(o *options) {
+		o.creationSite = c
+	}
+}
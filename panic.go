@@ -2,54 +2,111 @@ package govnr
 
 import (
 	"fmt"
-	"github.com/pkg/errors"
 	"runtime"
 	"strings"
+	"time"
 )
 
+// Emitted to the Errorer when a governed function panics.
+// If the value passed to panic() is itself an error, it can be matched by errors.Is and errors.As through Unwrap.
+type PanicError struct {
+	// The value passed to panic()
+	Value interface{}
+	// The stack of the panicking goroutine, starting at the function that panicked
+	Stack []Frame
+	// The name given to Forever, ForeverCtx, Go or a ChildSpec; empty for Once and Recover
+	Name string
+	// The number of the run of f() that panicked, starting at 1; always 1 for Once, Go and Recover
+	Attempt   int
+	Time      time.Time
+	CreatedBy []Frame
+}
+
+// A single function call in the stack of a PanicError
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("%s\n\t%s:%d", f.Function, f.File, f.Line)
+}
+
+func (e *PanicError) Error() string {
+	msg := fmt.Sprintf("\npanic: %v\n\ngoroutine panicked at:\n%s\n\n", e.Value, e.location())
+	if len(e.CreatedBy) > 0 {
+		frames := make([]string, len(e.CreatedBy))
+		for i, f := range e.CreatedBy {
+			frames[i] = f.String()
+		}
+		msg += fmt.Sprintf("goroutine created at:\n%s\n\n", strings.Join(frames, "\n"))
+	}
+	return msg
+}
+
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// avoids returning a typed nil as a non-nil error
+func (e *PanicError) asError() error {
+	if e == nil {
+		return nil
+	}
+	return e
+}
+
+func (e *PanicError) location() string {
+	if len(e.Stack) == 0 {
+		return "unknown"
+	}
+	if f := e.Stack[0]; f.Function != "" {
+		return fmt.Sprintf("%v:%v", f.Function, f.Line)
+	}
+	return fmt.Sprintf("%v:%v", e.Stack[0].File, e.Stack[0].Line)
+}
+
 // Runs f() on the original goroutine; if it panics, logs the error and stack trace to the specified Errorer
 // Very similar to GoOnce except doesn't start a new goroutine
-func Recover(errorHandler Errorer, f func()) {
-	tryOnce(errorHandler, f)
+func Recover(errorHandler Errorer, f func(), opts ...Option) {
+	o := newOptions(opts)
+	o.observers.tryOnce(nextGoroutineID(), errorHandler, "", captureCreationSite(o.creationSite, 1), 1, f)
 }
 
 // this function is needed so that we don't return out of the goroutine when it panics
-func tryOnce(errorHandler Errorer, f func()) {
-	defer recoverPanics(errorHandler)
+// returns the reported PanicError if f() panicked, nil otherwise
+func tryOnce(errorHandler Errorer, name string, createdBy []Frame, attempt int, f func()) (panicErr *PanicError) {
+	defer recoverPanics(errorHandler, name, createdBy, attempt, &panicErr)
 	f()
+	return
 }
 
-func recoverPanics(errorHandler Errorer) {
+func recoverPanics(errorHandler Errorer, name string, createdBy []Frame, attempt int, panicErr **PanicError) {
 	if p := recover(); p != nil {
-		errorHandler.Error(errors.Errorf("\npanic: %v\n\ngoroutine panicked at:\n%s\n\n", p, identifyPanic()))
+		*panicErr = &PanicError{Value: p, Stack: panicStack(), Name: name, Attempt: attempt, Time: time.Now(), CreatedBy: createdBy}
+		errorHandler.Error(*panicErr)
 	}
 }
 
-func identifyPanic() string {
-	var name, file string
-	var line int
-	var pc [16]uintptr
-
+// must be called directly from the deferred function that recovered the panic
+func panicStack() []Frame {
+	var pc [64]uintptr
 	n := runtime.Callers(3, pc[:])
-	for _, pc := range pc[:n] {
-		fn := runtime.FuncForPC(pc)
-		if fn == nil {
-			continue
+	frames := runtime.CallersFrames(pc[:n])
+
+	var stack []Frame
+	for {
+		frame, more := frames.Next()
+		if len(stack) > 0 || !strings.HasPrefix(frame.Function, "runtime.") { // skip the frames of the panic machinery itself
+			stack = append(stack, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
 		}
-		file, line = fn.FileLine(pc)
-		name = fn.Name()
-		if !strings.HasPrefix(name, "runtime.") {
+		if !more {
 			break
 		}
 	}
-
-	switch {
-	case name != "":
-		return fmt.Sprintf("%v:%v", name, line)
-	case file != "":
-		return fmt.Sprintf("%v:%v", file, line)
-	}
-
-	return fmt.Sprintf("pc:%x", pc)
+	return stack
 }
-
@@ -0,0 +1,53 @@
+package govnr
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+var errFromPanic = errors.New("an error passed to panic()")
+
+func localFunctionThatPanicsWithError() {
+	panic(errFromPanic)
+}
+
+func TestRecover_ReportsPanicError(t *testing.T) {
+	logger := bufferedLogger()
+
+	Recover(logger, localFunctionThatPanicsWithError)
+
+	report := <-logger.errors
+	var panicErr *PanicError
+	require.True(t, errors.As(report.err, &panicErr), "reported error is not a PanicError")
+	require.Equal(t, errFromPanic, panicErr.Value)
+	require.Equal(t, 1, panicErr.Attempt)
+	require.False(t, panicErr.Time.IsZero())
+	require.True(t, errors.Is(report.err, errFromPanic), "PanicError doesn't unwrap to the value passed to panic()")
+	require.True(t, strings.HasSuffix(panicErr.Stack[0].Function, "localFunctionThatPanicsWithError"), "stack doesn't start at the panic site: %v", panicErr.Stack)
+	require.Contains(t, report.err.Error(), "localFunctionThatPanicsWithError")
+}
+
+func TestPanicError_UnwrapsToNilForNonErrorValues(t *testing.T) {
+	require.Nil(t, errors.Unwrap(&PanicError{Value: "foo"}))
+}
+
+func TestForever_PanicErrorCarriesNameAndAttempt(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	Forever(ctx, "some service", logger, func() {
+		panic("foo")
+	}).MarkSupervised()
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		report := <-logger.errors
+		var panicErr *PanicError
+		require.True(t, errors.As(report.err, &panicErr), "reported error is not a PanicError")
+		require.Equal(t, "some service", panicErr.Name)
+		require.Equal(t, attempt, panicErr.Attempt)
+	}
+}
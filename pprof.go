@@ -0,0 +1,177 @@
+package govnr
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The pprof labels applied to every governed goroutine, and inherited by any goroutine it starts, so that
+// CPU profiles and goroutine dumps can be grouped by governed goroutine, e.g. with `go tool pprof -tagfocus govnr.parent=node`
+const (
+	// The name given to Forever, ForeverCtx, Go or a ChildSpec
+	NameLabel = "govnr.name"
+	// The hierarchical name of the supervisor whose Context the goroutine was started with, if any
+	ParentLabel = "govnr.parent"
+	// Uniquely identifies the governed goroutine within the process, as the ID of its Observer Events
+	IDLabel = "govnr.id"
+)
+
+// A group of goroutines sharing the same stack and pprof labels, as listed by GoroutineStacks
+type GoroutineStack struct {
+	Count  int
+	Labels map[string]string
+	Stack  []Frame
+}
+
+func (s GoroutineStack) String() string {
+	frames := make([]string, len(s.Stack))
+	for i, f := range s.Stack {
+		frames[i] = f.String()
+	}
+	return fmt.Sprintf("%d goroutine(s) labelled %v:\n%s", s.Count, s.Labels, strings.Join(frames, "\n"))
+}
+
+// Lists the current stacks of the governed goroutines named name. name may be either the name the goroutine was started with,
+// or its full hierarchical name, including the name of the supervisor whose Context it was started with, e.g. "node/consensus/sync"
+func GoroutineStacks(name string) []GoroutineStack {
+	var stacks []GoroutineStack
+	for _, s := range goroutineProfile() {
+		if s.Labels[NameLabel] == name || joinName(s.Labels[ParentLabel], s.Labels[NameLabel]) == name {
+			stacks = append(stacks, s)
+		}
+	}
+	return stacks
+}
+
+// lists the stacks of the governed goroutines identified by ids, along with any goroutine they started, since they inherit their labels.
+// The profile is taken once for all the goroutines timing out during the shutdown shutdownContext belongs to, see withSharedProfile
+func goroutineStacksByID(shutdownContext context.Context, ids ...uint64) []GoroutineStack {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[strconv.FormatUint(id, 10)] = true
+	}
+	var stacks []GoroutineStack
+	for _, s := range sharedProfileOf(shutdownContext).get() {
+		if wanted[s.Labels[IDLabel]] {
+			stacks = append(stacks, s)
+		}
+	}
+	return stacks
+}
+
+type sharedProfileKey struct{}
+
+// a goroutine profile taken when first needed, then reused
+type sharedProfile struct {
+	once   sync.Once
+	stacks []GoroutineStack
+}
+
+func (p *sharedProfile) get() []GoroutineStack {
+	p.once.Do(func() {
+		p.stacks = goroutineProfile()
+	})
+	return p.stacks
+}
+
+// returns shutdownContext carrying a sharedProfile, unless it already carries one, as when a supervisor is shut down by its parent
+func withSharedProfile(shutdownContext context.Context) context.Context {
+	if _, ok := shutdownContext.Value(sharedProfileKey{}).(*sharedProfile); ok {
+		return shutdownContext
+	}
+	return context.WithValue(shutdownContext, sharedProfileKey{}, &sharedProfile{})
+}
+
+// returns the sharedProfile shutdownContext carries, or a new one if it doesn't
+func sharedProfileOf(shutdownContext context.Context) *sharedProfile {
+	if p, ok := shutdownContext.Value(sharedProfileKey{}).(*sharedProfile); ok {
+		return p
+	}
+	return &sharedProfile{}
+}
+
+// parses the debug=1 goroutine profile, listing only goroutines labelled with NameLabel
+func goroutineProfile() []GoroutineStack {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return nil
+	}
+
+	var stacks []GoroutineStack
+	var current *GoroutineStack
+	flush := func() {
+		if current != nil {
+			if _, governed := current.Labels[NameLabel]; governed {
+				stacks = append(stacks, *current)
+			}
+		}
+		current = nil
+	}
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "# labels: "):
+			if current != nil {
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "# labels: ")), &current.Labels)
+			}
+		case strings.HasPrefix(line, "#\t"):
+			if current != nil {
+				current.Stack = append(current.Stack, parseProfileFrame(line))
+			}
+		default: // "<count> @ <addresses>"
+			if count, err := strconv.Atoi(strings.SplitN(line, " ", 2)[0]); err == nil {
+				flush()
+				current = &GoroutineStack{Count: count}
+			}
+		}
+	}
+	flush()
+	return stacks
+}
+
+// parses "#\t<address>\t<function>+<offset>\t<file>:<line>"
+func parseProfileFrame(line string) Frame {
+	fields := strings.Fields(line)
+	var frame Frame
+	if len(fields) > 2 {
+		frame.Function = fields[2]
+		if i := strings.LastIndex(frame.Function, "+0x"); i > 0 {
+			frame.Function = frame.Function[:i]
+		}
+	}
+	if len(fields) > 3 {
+		location := strings.Join(fields[3:], " ")
+		if i := strings.LastIndex(location, ":"); i > 0 {
+			frame.File = location[:i]
+			frame.Line, _ = strconv.Atoi(location[i+1:])
+		}
+	}
+	return frame
+}
+
+// runs f() with the pprof labels of a governed goroutine named name, started with ctx; the ctx passed to f() carries the labels.
+// IDLabel is omitted if id is zero, as for the goroutines of supervisors
+func withLabels(ctx context.Context, id uint64, name string, f func(ctx context.Context)) {
+	parent, _ := pprof.Label(ctx, ParentLabel)
+	labels := []string{NameLabel, name, ParentLabel, parent}
+	if id != 0 {
+		labels = append(labels, IDLabel, strconv.FormatUint(id, 10))
+	}
+	pprof.Do(ctx, pprof.Labels(labels...), f)
+}
+
+// returns ctx labelled as the Context of a supervisor named name, nested under any supervisor ctx already belongs to
+func supervisorContext(ctx context.Context, name string) context.Context {
+	parent, _ := pprof.Label(ctx, ParentLabel)
+	return pprof.WithLabels(ctx, pprof.Labels(ParentLabel, joinName(parent, name)))
+}
@@ -0,0 +1,103 @@
+package govnr
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestForever_LabelsGoroutineWithNameAndParent(t *testing.T) {
+	logger := bufferedLogger()
+	s := NewTreeSupervisor(context.Background(), "node")
+	consensus := NewStrategySupervisor(s.Context(), "consensus", logger, OneForOne)
+	s.Supervise(consensus)
+
+	labels := make(chan [2]string, 1)
+	consensus.StartChild(ChildSpec{Name: "sync", Start: func(ctx context.Context) error {
+		name, _ := pprof.Label(ctx, NameLabel)
+		parent, _ := pprof.Label(ctx, ParentLabel)
+		labels <- [2]string{name, parent}
+		<-ctx.Done()
+		return nil
+	}})
+	require.Equal(t, [2]string{"sync", "node/consensus"}, <-labels)
+
+	s.Supervise(ForeverCtx(s.Context(), "gossip", logger, func(ctx context.Context) error {
+		name, _ := pprof.Label(ctx, NameLabel)
+		parent, _ := pprof.Label(ctx, ParentLabel)
+		labels <- [2]string{name, parent}
+		<-ctx.Done()
+		return nil
+	}))
+	require.Equal(t, [2]string{"gossip", "node"}, <-labels)
+
+	s.Shutdown(context.Background())
+}
+
+func blockUntilReleased(started chan struct{}, release <-chan struct{}) {
+	close(started)
+	<-release
+}
+
+func TestGoroutineStacks_ListsStacksOfGoroutinesMatchingName(t *testing.T) {
+	logger := bufferedLogger()
+	s := NewTreeSupervisor(context.Background(), "node")
+	ctx := s.Context()
+	started := make(chan struct{})
+	h := Forever(ctx, "stacks-test", logger, func() {
+		blockUntilReleased(started, ctx.Done())
+	})
+	s.Supervise(h)
+	<-started
+
+	for _, name := range []string{"stacks-test", "node/stacks-test"} {
+		stacks := GoroutineStacks(name)
+		require.Len(t, stacks, 1, "expected a single stack for %s", name)
+		require.Equal(t, 1, stacks[0].Count)
+		require.Equal(t, map[string]string{NameLabel: "stacks-test", ParentLabel: "node", IDLabel: strconv.FormatUint(h.id, 10)}, stacks[0].Labels)
+
+		var functions []string
+		for _, frame := range stacks[0].Stack {
+			functions = append(functions, frame.Function)
+		}
+		require.Contains(t, strings.Join(functions, "\n"), "govnr.blockUntilReleased")
+		require.NotZero(t, stacks[0].Stack[0].Line)
+		require.NotEmpty(t, stacks[0].Stack[0].File)
+	}
+	require.Empty(t, GoroutineStacks("node"))
+	require.Empty(t, GoroutineStacks("other/stacks-test"))
+
+	s.Shutdown(context.Background())
+}
+
+func TestGoroutineStacksByID_TakesOneProfilePerShutdown(t *testing.T) {
+	logger := bufferedLogger()
+	s := NewTreeSupervisor(context.Background(), "node")
+	ctx := s.Context()
+	firstStarted, secondStarted := make(chan struct{}), make(chan struct{})
+	first := s.Forever("first", logger, func() {
+		blockUntilReleased(firstStarted, ctx.Done())
+	})
+	<-firstStarted
+
+	shutdownCtx := withSharedProfile(context.Background())
+	require.Equal(t, shutdownCtx, withSharedProfile(shutdownCtx), "should reuse the profile of the enclosing shutdown")
+	require.Len(t, goroutineStacksByID(shutdownCtx, first.id), 1)
+
+	second := s.Forever("second", logger, func() {
+		blockUntilReleased(secondStarted, ctx.Done())
+	})
+	<-secondStarted
+	require.Empty(t, goroutineStacksByID(shutdownCtx, second.id), "should reuse the profile taken before second started")
+	require.Len(t, goroutineStacksByID(context.Background(), first.id, second.id), 2)
+
+	s.Shutdown(context.Background())
+}
+
+func TestParseProfileFrame(t *testing.T) {
+	frame := parseProfileFrame("#\t0x4e145c\tmain.main.func1+0x1c\t\t/tmp/my project/main.go:11")
+	require.Equal(t, Frame{Function: "main.main.func1", File: "/tmp/my project/main.go", Line: 11}, frame)
+}
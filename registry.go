@@ -0,0 +1,71 @@
+package govnr
+
+import "time"
+
+// The state of a governed goroutine, as seen in a GoroutineInfo
+type GoroutineState int
+
+const (
+	Running GoroutineState = iota
+	// Between runs, about to run again
+	Restarting
+	// Waiting before running again, according to a RestartPolicy or a BusyRestartPolicy
+	BackingOff
+	// No longer running and won't run again
+	Stopped
+)
+
+func (s GoroutineState) String() string {
+	switch s {
+	case Running:
+		return "running"
+	case Restarting:
+		return "restarting"
+	case BackingOff:
+		return "backing off"
+	case Stopped:
+		return "stopped"
+	}
+	return "unknown"
+}
+
+func (s GoroutineState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// A point in time description of a single governed goroutine
+type GoroutineInfo struct {
+	// The hierarchical name of the goroutine, made of the names of its enclosing supervisors and its own, separated by "/"
+	Name string
+	// The hierarchical name of the supervisor the goroutine was passed to, empty if it is at the top of the snapshot
+	Parent   string
+	State    GoroutineState
+	Started  time.Time
+	Restarts int
+	// The error reported for the most recent panic, or for ForeverCtx and StrategySupervisor children also returned error, if any
+	LastErr   error
+	CreatedBy []Frame
+}
+
+// Implemented by ShutdownWaiters that can describe the goroutines they govern, such as ForeverHandle, OnceHandle,
+// TreeSupervisor and StrategySupervisor. Calling Snapshot on the root of a supervision tree lists every governed goroutine in the tree.
+type Introspector interface {
+	Snapshot() []GoroutineInfo
+}
+
+// Implemented by supervisors, which DebugHandler renders as nodes of the tree even when they govern no goroutine.
+// Returns the goroutines the supervisor runs itself, named relative to it, and the children it supervises.
+type supervisorIntrospector interface {
+	Introspector
+	supervisorName() string
+	introspect() (goroutines []GoroutineInfo, children []Introspector)
+}
+
+// makes infos, as described by a child of the supervisor named prefix, relative to the supervisor's parent
+func prefixSnapshot(prefix string, infos []GoroutineInfo) []GoroutineInfo {
+	for i := range infos {
+		infos[i].Name = joinName(prefix, infos[i].Name)
+		infos[i].Parent = joinName(prefix, infos[i].Parent)
+	}
+	return infos
+}
@@ -0,0 +1,71 @@
+package govnr
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTreeSupervisor_SnapshotListsEveryGovernedGoroutine(t *testing.T) {
+	logger := bufferedLogger()
+	root := NewTreeSupervisor(context.Background(), "node")
+	ctx := root.Context()
+
+	consensus := &TreeSupervisor{Name: "consensus"}
+	root.Supervise(consensus)
+	started := make(chan struct{}, 1)
+	consensus.Supervise(Forever(ctx, "sync", logger, func() {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-ctx.Done()
+	}))
+	consensus.Supervise(Forever(ctx, "crashing", logger, func() {
+		panic("foo")
+	}, WithRestartPolicy(RestartPolicy{Backoff: ConstantBackoff(time.Hour)})))
+
+	strategy := NewStrategySupervisor(ctx, "workers", logger, OneForOne)
+	strategy.StartChild(ChildSpec{Name: "worker", Start: func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}})
+	root.Supervise(strategy)
+
+	release := make(chan struct{})
+	once := Go("once", logger, func() {
+		<-release
+	})
+	root.Supervise(once)
+	close(release)
+	<-once.Done()
+	<-started
+	<-logger.errors // crashing panicked, now backing off
+
+	infos := root.Snapshot()
+	require.Len(t, infos, 4)
+
+	require.Equal(t, "node/consensus/sync", infos[0].Name)
+	require.Equal(t, "node/consensus", infos[0].Parent)
+	require.Equal(t, Running, infos[0].State)
+	require.False(t, infos[0].Started.IsZero())
+
+	require.Equal(t, "node/consensus/crashing", infos[1].Name)
+	require.Equal(t, BackingOff, infos[1].State)
+	require.Equal(t, 1, infos[1].Restarts)
+	require.IsType(t, &PanicError{}, infos[1].LastErr)
+
+	require.Equal(t, "node/workers/worker", infos[2].Name)
+	require.Equal(t, "node/workers", infos[2].Parent)
+	require.Equal(t, Running, infos[2].State)
+
+	require.Equal(t, "node/once", infos[3].Name)
+	require.Equal(t, "node", infos[3].Parent)
+	require.Equal(t, Stopped, infos[3].State)
+
+	root.Shutdown(context.Background())
+	for _, info := range root.Snapshot() {
+		require.Equal(t, Stopped, info.State, "%s is still %s after shutdown", info.Name, info.State)
+	}
+}
@@ -0,0 +1,98 @@
+package govnr
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Implemented by ShutdownWaiters that can tell how their shutdown went, such as ForeverHandle, OnceHandle and TreeSupervisor
+type ShutdownReporter interface {
+	ShutdownWaiter
+	// Like WaitUntilShutdown, but returns a ShutdownReport with an entry for every governed goroutine waited on
+	WaitUntilShutdownWithReport(shutdownContext context.Context) *ShutdownReport
+}
+
+// The outcome of waiting for a single supervised child during shutdown
+type ChildShutdown struct {
+	// The hierarchical name of the child, made of the names of its enclosing TreeSupervisors and its own, separated by "/"
+	Name string
+	// How long it took the child to stop, or how long it was waited on if it timed out
+	Duration time.Duration
+	TimedOut bool
+	// The last error the child reported, if any
+	Err error
+}
+
+// The outcome of waiting for a supervision tree during shutdown, rolled up from all nested supervisors
+type ShutdownReport struct {
+	Children []ChildShutdown
+}
+
+// Returns true if every child stopped before the shutdown Context closed
+func (r *ShutdownReport) Clean() bool {
+	return len(r.TimedOut()) == 0
+}
+
+// Returns the children that were still running when the shutdown Context closed
+func (r *ShutdownReport) TimedOut() []ChildShutdown {
+	var timedOut []ChildShutdown
+	for _, c := range r.Children {
+		if c.TimedOut {
+			timedOut = append(timedOut, c)
+		}
+	}
+	return timedOut
+}
+
+// Returns up to n children which took longest to stop, slowest first
+func (r *ShutdownReport) Slowest(n int) []ChildShutdown {
+	sorted := make([]ChildShutdown, len(r.Children))
+	copy(sorted, r.Children)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Duration > sorted[j].Duration
+	})
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+func (r *ShutdownReport) prefixed(prefix string) *ShutdownReport {
+	children := make([]ChildShutdown, len(r.Children))
+	for i, c := range r.Children {
+		c.Name = joinName(prefix, c.Name)
+		children[i] = c
+	}
+	return &ShutdownReport{Children: children}
+}
+
+// joins supervision tree names into a path, skipping unnamed levels
+func joinName(parts ...string) string {
+	var nonEmpty []string
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, "/")
+}
+
+// waits for a ShutdownWaiter that doesn't implement ShutdownReporter, which is assumed to have timed out if it returned after shutdownContext closed
+func waitWithReport(w ShutdownWaiter, shutdownContext context.Context) *ShutdownReport {
+	if r, ok := w.(ShutdownReporter); ok {
+		return r.WaitUntilShutdownWithReport(shutdownContext)
+	}
+	started := time.Now()
+	w.WaitUntilShutdown(shutdownContext)
+	return &ShutdownReport{Children: []ChildShutdown{{Name: nameOf(w), Duration: time.Since(started), TimedOut: shutdownContext.Err() != nil}}}
+}
+
+func nameOf(w ShutdownWaiter) string {
+	if n, ok := w.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", w)
+}
@@ -0,0 +1,61 @@
+package govnr
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTreeSupervisor_ReportRollsUpNestedSupervisors(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inner := &TreeSupervisor{Name: "consensus"}
+	inner.Supervise(Forever(ctx, "sync", logger, func() {
+		<-ctx.Done()
+	}))
+	stuck, releaseStuck := context.WithCancel(context.Background())
+	defer releaseStuck()
+	inner.Supervise(Forever(stuck, "stuck", logger, func() {
+		<-stuck.Done()
+	}))
+
+	outer := &TreeSupervisor{Name: "node"}
+	outer.Supervise(inner)
+	outer.Supervise(Go("once", logger, func() {}))
+	outer.Supervise(&countingWaiter{})
+
+	cancel()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer shutdownCancel()
+	report := outer.WaitUntilShutdownWithReport(shutdownCtx)
+
+	require.Len(t, report.Children, 4)
+	require.Equal(t, "node/consensus/sync", report.Children[0].Name)
+	require.False(t, report.Children[0].TimedOut)
+	require.Equal(t, "node/consensus/stuck", report.Children[1].Name)
+	require.True(t, report.Children[1].TimedOut)
+	require.Equal(t, "node/once", report.Children[2].Name)
+	require.Equal(t, "node/*govnr.countingWaiter", report.Children[3].Name)
+
+	require.False(t, report.Clean())
+	require.Len(t, report.TimedOut(), 1)
+	require.Equal(t, "node/consensus/stuck", report.Slowest(1)[0].Name)
+}
+
+func TestForeverHandle_ReportIncludesLastError(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h := Forever(ctx, "panicky", logger, func() {
+		cancel()
+		panic("foo")
+	})
+	h.MarkSupervised()
+
+	report := h.WaitUntilShutdownWithReport(context.Background())
+	require.True(t, report.Clean())
+	require.IsType(t, &PanicError{}, report.Children[0].Err)
+}
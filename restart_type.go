@@ -0,0 +1,36 @@
+package govnr
+
+// Determines whether a governed goroutine is restarted when it exits, following the restart types of Erlang/OTP child specs
+type RestartType int
+
+const (
+	// Always restarted, whether it panicked, returned an error or returned normally. The default for Forever and for a ChildSpec
+	Permanent RestartType = iota
+	// Restarted only if it panicked or returned an error, and finishes quietly on a clean return. The default for ForeverCtx
+	Transient
+	// Never restarted, but still supervised and waited on during shutdown
+	Temporary
+)
+
+func (t RestartType) String() string {
+	switch t {
+	case Permanent:
+		return "permanent"
+	case Transient:
+		return "transient"
+	case Temporary:
+		return "temporary"
+	}
+	return "unknown"
+}
+
+// returns true if a goroutine of this type should be restarted after exiting with err, which is nil on a clean return
+func (t RestartType) restarts(err error) bool {
+	switch t {
+	case Permanent:
+		return true
+	case Transient:
+		return err != nil
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package govnr
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func runsUntilShutdown(t *testing.T, h *ForeverHandle) {
+	h.MarkSupervised()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	require.True(t, h.WaitUntilShutdownWithReport(shutdownCtx).Clean(), "goroutine didn't terminate")
+}
+
+func TestForever_TransientFinishesOnCleanReturnButRestartsOnPanic(t *testing.T) {
+	logger := bufferedLogger()
+	runs := 0
+	runsUntilShutdown(t, Forever(context.Background(), "transient", logger, func() {
+		runs++
+		if runs == 1 {
+			panic("foo")
+		}
+	}, WithRestartType(Transient)))
+	require.Equal(t, 2, runs)
+}
+
+func TestForever_TemporaryIsNeverRestarted(t *testing.T) {
+	logger := bufferedLogger()
+	runs := 0
+	runsUntilShutdown(t, Forever(context.Background(), "temporary", logger, func() {
+		runs++
+		panic("foo")
+	}, WithRestartType(Temporary)))
+	require.Equal(t, 1, runs)
+}
+
+func TestForeverCtx_PermanentRestartsOnCleanReturn(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	runsUntilShutdown(t, ForeverCtx(ctx, "permanent", logger, func(ctx context.Context) error {
+		runs++
+		if runs == 3 {
+			cancel()
+		}
+		return nil
+	}, WithRestartType(Permanent)))
+	require.Equal(t, 3, runs)
+}
+
+type terminateObserver struct {
+	NopObserver
+	terminated chan Event
+}
+
+func (o *terminateObserver) OnTerminate(e Event) {
+	o.terminated <- e
+}
+
+func TestStrategySupervisor_DoesNotRestartFinishedOrTemporaryChildren(t *testing.T) {
+	logger := bufferedLogger()
+	counter := newStartCounter()
+	observer := &terminateObserver{terminated: make(chan Event, 10)}
+	s := NewStrategySupervisor(context.Background(), "sup", logger, OneForAll, WithObserver(observer))
+
+	s.StartChild(ChildSpec{Name: "transient", Restart: Transient, Start: func(ctx context.Context) error {
+		counter.Lock()
+		counter.starts["transient"]++
+		counter.Unlock()
+		return nil
+	}})
+	require.Equal(t, "sup/transient", (<-observer.terminated).Name, "the supervisor didn't see the clean exit")
+
+	temporary := counter.spec("temporary", false)
+	temporary.Restart = Temporary
+	s.StartChild(temporary)
+
+	release := make(chan struct{})
+	s.StartChild(ChildSpec{Name: "failing", Start: func(ctx context.Context) error {
+		<-release
+		return counter.spec("failing", true).Start(ctx)
+	}})
+	close(release)
+
+	counter.awaitStarts(t, 3) // temporary, and failing twice
+	require.Equal(t, "sup/temporary", (<-observer.terminated).Name)
+
+	require.Equal(t, 2, counter.count("failing"))
+	require.Equal(t, 1, counter.count("transient"), "finished transient child was restarted along with its sibling")
+	require.Equal(t, 1, counter.count("temporary"), "temporary child was restarted along with its sibling")
+	require.True(t, s.Shutdown(context.Background()).Clean())
+}
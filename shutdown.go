@@ -2,6 +2,7 @@ package govnr
 
 import (
 	"context"
+	"runtime/trace"
 	"sync"
 )
 
@@ -15,41 +16,309 @@ type Supervisor interface {
 	Supervise(w ShutdownWaiter)
 }
 
+// supervises w before its goroutine starts, see startForever and startGo; returns false if w was refused
+type superviseFunc func(w ShutdownWaiter) bool
+
+// a Supervisor that can refuse a ShutdownWaiter instead of panicking, as a TreeSupervisor does once it has shut down
+type refusingSupervisor interface {
+	trySupervise(w ShutdownWaiter) bool
+}
+
 type supervisedMarker interface {
 	MarkSupervised()
 }
 
+type restartIntensityInheritor interface {
+	inheritRestartIntensity(i RestartIntensity)
+}
+
+type observerInheritor interface {
+	inheritObservers(o observers)
+}
+
 // Useful for creating supervision trees; that is, nested object graphs that spawn long-running goroutines where the top level
 // object needs to block until all goroutines in the systems have shut down. As such, TreeSupervisor is both a Supervisor and a ShutdownWaiter.
-// When WaitUntilShutdown is called, it will in turn call WaitUntilShutdown on all of its Supervised ShutdownWaiters.
+// When WaitUntilShutdown is called, it will in turn call WaitUntilShutdown on all of its Supervised ShutdownWaiters concurrently,
+// at most ShutdownConcurrency at a time if it is set.
+//
+// If RestartIntensity is set, it applies to every supervised ForeverHandle that wasn't started WithRestartIntensity.
+//
+// WaitUntilShutdownWithReport rolls up a ShutdownReport for every child, prefixing their names with Name.
+// If Metrics or Observer are set, they observe the goroutines it supervises and its shutdown. When runtime/trace is enabled,
+// the wait is traced as a "govnr.Shutdown" task with a region per child, nesting the tasks of supervised TreeSupervisors.
 //
-// Note that after calling WaitUntilShutdown it is no longer possible to call Supervise, and any subsequent call will panic.
+// A TreeSupervisor owns a Context, available through Context(), which should be passed to the goroutines it supervises;
+// Shutdown cancels that Context and then waits for the whole tree. A TreeSupervisor created with NewTreeSupervisor derives its Context
+// from the provided parent, while the zero value derives it from context.Background(). The Context carries the TreeSupervisor, see WithSupervisor.
+//
+// Note that after calling WaitUntilShutdown it is no longer possible to call Supervise, SuperviseChild, NewChild or the methods starting supervised goroutines,
+// and any subsequent call will panic.
 type TreeSupervisor struct {
-	supervised            []ShutdownWaiter
+	Name                string
+	RestartIntensity    RestartIntensity
+	ShutdownConcurrency int
+	Metrics             *Metrics
+	Observer            Observer
+
+	supervised            []*SupervisedChild // only ever appended to or replaced, so that copies of it can be iterated without holding the lock
+	membership            *SupervisedChild   // through which the parent supervises t, if t was created with NewChild
 	waitForShutdownCalled struct {
 		sync.Mutex
 		called bool
 	}
+	root struct {
+		sync.Once
+		ctx    context.Context
+		cancel context.CancelFunc
+	}
+}
+
+// Creates a TreeSupervisor whose Context is derived from parent
+func NewTreeSupervisor(parent context.Context, name string) *TreeSupervisor {
+	t := &TreeSupervisor{Name: name}
+	t.initContext(parent)
+	return t
+}
+
+// Creates a TreeSupervisor named name, supervised by t as a SupervisedChild, whose Context is derived from t's. Shutting the child down
+// stops only the goroutines started with its Context, e.g. a single subsystem, and removes it from t while the rest of the tree keeps running;
+// shutting t down shuts the child down too. The child inherits t's RestartIntensity, ShutdownConcurrency, Metrics and Observer.
+// Names join into hierarchical paths such as node/consensus/sync.
+func (t *TreeSupervisor) NewChild(name string) *TreeSupervisor {
+	child := &TreeSupervisor{Name: name, RestartIntensity: t.RestartIntensity, ShutdownConcurrency: t.ShutdownConcurrency, Metrics: t.Metrics, Observer: t.Observer}
+	child.membership = t.SuperviseChild(func(ctx context.Context) ShutdownWaiter {
+		child.initContext(ctx)
+		return child
+	})
+	return child
+}
+
+// returns the hierarchical name of t, e.g. node/consensus/sync, joining the names of the TreeSupervisors it was created by with NewChild
+func (t *TreeSupervisor) path() string {
+	if t.membership == nil {
+		return t.Name
+	}
+	return joinName(t.membership.parent.path(), t.Name)
+}
+
+func (t *TreeSupervisor) initContext(parent context.Context) {
+	t.root.Do(func() {
+		t.root.ctx, t.root.cancel = context.WithCancel(WithSupervisor(supervisorContext(parent, t.Name), t))
+	})
+}
+
+// Returns the Context owned by the TreeSupervisor, which is closed when Shutdown is called
+func (t *TreeSupervisor) Context() context.Context {
+	t.initContext(context.Background())
+	return t.root.ctx
+}
+
+// Cancels the Context owned by the TreeSupervisor, then waits for all supervised children to shut down as WaitUntilShutdownWithReport does.
+// A TreeSupervisor created with NewChild is then removed from its parent, and its ShutdownReport is named after its full path
+func (t *TreeSupervisor) Shutdown(shutdownContext context.Context) *ShutdownReport {
+	t.initContext(context.Background())
+	t.root.cancel()
+	if t.membership != nil {
+		return t.membership.Stop(shutdownContext)
+	}
+	return t.WaitUntilShutdownWithReport(shutdownContext)
 }
 
 func (t *TreeSupervisor) WaitUntilShutdown(shutdownContext context.Context) {
+	t.WaitUntilShutdownWithReport(shutdownContext)
+}
+
+func (t *TreeSupervisor) WaitUntilShutdownWithReport(shutdownContext context.Context) *ShutdownReport {
+	shutdownContext, endTask := traceTask(withSharedProfile(shutdownContext), "govnr.Shutdown", t.Name)
+	defer endTask()
+	return t.observers().shutdown(0, t.Name, func() *ShutdownReport {
+		return t.waitForChildren(shutdownContext)
+	})
+}
+
+func (t *TreeSupervisor) observers() observers {
+	var o observers
+	if t.Metrics != nil {
+		o = append(o, t.Metrics)
+	}
+	if t.Observer != nil {
+		o = append(o, t.Observer)
+	}
+	return o
+}
+
+func (t *TreeSupervisor) waitForChildren(shutdownContext context.Context) *ShutdownReport {
 	t.waitForShutdownCalled.Lock()
-	defer t.waitForShutdownCalled.Unlock()
 	t.waitForShutdownCalled.called = true
-	for _, w := range t.supervised {
-		w.WaitUntilShutdown(shutdownContext)
+	supervised := t.supervised // can no longer grow, since Supervise panics from now on
+	t.waitForShutdownCalled.Unlock()
+
+	var wg sync.WaitGroup
+	reports := make([]*ShutdownReport, len(supervised))
+	slots := newShutdownSlots(t.ShutdownConcurrency)
+	for i, c := range supervised {
+		wg.Add(1)
+		go func(i int, w ShutdownWaiter) {
+			defer wg.Done()
+			defer slots.acquire(shutdownContext)()
+			defer trace.StartRegion(shutdownContext, nameOf(w)).End()
+			reports[i] = waitWithReport(w, shutdownContext)
+		}(i, c.w)
+	}
+	wg.Wait()
+
+	report := &ShutdownReport{}
+	for _, r := range reports {
+		report.Children = append(report.Children, r.prefixed(t.Name).Children...)
+	}
+	for _, c := range report.Children {
+		if c.TimedOut {
+			traceLogf(shutdownContext, "govnr.shutdown timeout", "%s", c.Name)
+		}
+	}
+	return report
+}
+
+// limits the number of children waited on concurrently; a nil shutdownSlots is unlimited
+type shutdownSlots chan struct{}
+
+func newShutdownSlots(concurrency int) shutdownSlots {
+	if concurrency <= 0 {
+		return nil
 	}
+	return make(shutdownSlots, concurrency)
+}
+
+// blocks until a slot is free, returning a function that frees it. Once shutdownContext is closed, returns right away
+// so that children still running past the deadline get to report it
+func (s shutdownSlots) acquire(shutdownContext context.Context) (release func()) {
+	if s == nil {
+		return func() {}
+	}
+	select {
+	case s <- struct{}{}:
+		return func() { <-s }
+	case <-shutdownContext.Done():
+		return func() {}
+	}
+}
+
+// Lists every governed goroutine in the tree, with names prefixed by Name; supervised ShutdownWaiters that aren't Introspectors are omitted
+func (t *TreeSupervisor) Snapshot() []GoroutineInfo {
+	_, children := t.introspect()
+	var infos []GoroutineInfo
+	for _, i := range children {
+		infos = append(infos, prefixSnapshot(t.Name, i.Snapshot())...)
+	}
+	return infos
+}
+
+func (t *TreeSupervisor) supervisorName() string {
+	return t.Name
+}
+
+func (t *TreeSupervisor) introspect() ([]GoroutineInfo, []Introspector) {
+	t.waitForShutdownCalled.Lock()
+	supervised := t.supervised
+	t.waitForShutdownCalled.Unlock()
+
+	var children []Introspector
+	for _, c := range supervised {
+		if i, ok := c.w.(Introspector); ok {
+			children = append(children, i)
+		}
+	}
+	return nil, children
 }
 
 func (t *TreeSupervisor) Supervise(w ShutdownWaiter) {
+	t.adopt(w)
+	t.add(&SupervisedChild{parent: t, w: w})
+}
+
+// marks w as supervised by t and passes it t's RestartIntensity and observers, without adding it to t's children
+func (t *TreeSupervisor) adopt(w ShutdownWaiter) {
 	if s, ok := w.(supervisedMarker); ok {
 		s.MarkSupervised()
 	}
+	if i, ok := w.(restartIntensityInheritor); ok && t.RestartIntensity.enabled() {
+		i.inheritRestartIntensity(t.RestartIntensity)
+	}
+	if i, ok := w.(observerInheritor); ok && len(t.observers()) > 0 {
+		i.inheritObservers(t.observers())
+	}
+}
 
+// supervises w as Supervise does, for the methods of t starting goroutines
+func (t *TreeSupervisor) mustSupervise(w ShutdownWaiter) bool {
+	t.Supervise(w)
+	return true
+}
+
+// supervises w as Supervise does, except that it returns false instead of panicking if called after WaitUntilShutdown
+func (t *TreeSupervisor) trySupervise(w ShutdownWaiter) bool {
+	t.adopt(w)
+	return t.tryAdd(&SupervisedChild{parent: t, w: w})
+}
+
+func (t *TreeSupervisor) add(c *SupervisedChild) {
+	if !t.tryAdd(c) {
+		panic("Can't call Supervise() after WaitUntilShutdown has been called")
+	}
+}
+
+// returns false, without adding c, if WaitUntilShutdown has been called
+func (t *TreeSupervisor) tryAdd(c *SupervisedChild) bool {
 	t.waitForShutdownCalled.Lock()
 	defer t.waitForShutdownCalled.Unlock()
 	if t.waitForShutdownCalled.called {
-		panic("Can't call Supervise() after WaitUntilShutdown has been called")
+		return false
+	}
+	t.supervised = append(t.supervised, c)
+	return true
+}
+
+func (t *TreeSupervisor) remove(c *SupervisedChild) {
+	t.waitForShutdownCalled.Lock()
+	defer t.waitForShutdownCalled.Unlock()
+	var supervised []*SupervisedChild
+	for _, s := range t.supervised {
+		if s != c {
+			supervised = append(supervised, s)
+		}
+	}
+	t.supervised = supervised
+}
+
+// Like the package level Forever started with t.Context(), except that the ForeverHandle is supervised by t before f() first runs.
+// Unlike calling Supervise on the returned ForeverHandle, this can't race with f() returning right away, e.g. because t is already shutting down,
+// which would be reported as an *UnsupervisedTerminationError.
+func (t *TreeSupervisor) Forever(name string, errorHandler Errorer, f func(), opts ...Option) *ForeverHandle {
+	return startForever(t.Context(), t.mustSupervise, name, errorHandler, opts, Permanent, runForever(errorHandler, name, f))
+}
+
+// Like the package level ForeverCtx started with t.Context(), except that the ForeverHandle is supervised by t before f() first runs,
+// see TreeSupervisor.Forever
+func (t *TreeSupervisor) ForeverCtx(name string, errorHandler Errorer, f func(ctx context.Context) error, opts ...Option) *ForeverHandle {
+	return startForever(t.Context(), t.mustSupervise, name, errorHandler, opts, Transient, runForeverCtx(errorHandler, name, f))
+}
+
+// Like the package level Go started with t.Context(), except that the OnceHandle is supervised by t before f() runs, see TreeSupervisor.Forever
+func (t *TreeSupervisor) Go(name string, errorHandler Errorer, f func(), opts ...Option) *OnceHandle {
+	return startGo(t.Context(), t.mustSupervise, name, errorHandler, f, opts)
+}
+
+// returns false if shutdownContext closed before closed did
+func waitUntilClosed(closed chan struct{}, shutdownContext context.Context) bool {
+	select {
+	case <-closed:
+		return true
+	default:
+	}
+	select {
+	case <-closed:
+		return true
+	case <-shutdownContext.Done():
+		return false
 	}
-	t.supervised = append(t.supervised, w)
 }
@@ -2,8 +2,12 @@ package govnr
 
 import (
 	"context"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
+	"runtime/pprof"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestTreeSupervisor_SuperviseAfterWaitForShutdown_Panics(t *testing.T) {
@@ -27,3 +31,163 @@ func TestTreeSupervisor_Supervise(t *testing.T) {
 }
 
 
+
+func TestTreeSupervisor_ReportsEveryChildStillRunningAtDeadline(t *testing.T) {
+	for _, concurrency := range []int{0, 1} {
+		logger := bufferedLogger()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		s := TreeSupervisor{ShutdownConcurrency: concurrency}
+		for i := 0; i < 3; i++ {
+			s.Supervise(Forever(ctx, "stuck", logger, func() {
+				<-ctx.Done()
+			}))
+		}
+
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		s.WaitUntilShutdown(shutdownCtx)
+		require.Len(t, logger.errors, 3, "not every child was reported (concurrency %d)", concurrency)
+		for i := 0; i < 3; i++ {
+			require.True(t, errors.Is((<-logger.errors).err, ErrShutdownTimeout))
+		}
+
+		shutdownCancel()
+		cancel()
+	}
+}
+
+func TestTreeSupervisor_ShutdownConcurrencyLimitsConcurrentWaits(t *testing.T) {
+	waiter := &countingWaiter{}
+	s := TreeSupervisor{ShutdownConcurrency: 2}
+	for i := 0; i < 5; i++ {
+		s.Supervise(waiter)
+	}
+	s.WaitUntilShutdown(context.Background())
+	require.EqualValues(t, 2, waiter.max)
+}
+
+type countingWaiter struct {
+	sync.Mutex
+	current, max int
+}
+
+func (w *countingWaiter) WaitUntilShutdown(shutdownContext context.Context) {
+	w.Lock()
+	w.current++
+	if w.current > w.max {
+		w.max = w.current
+	}
+	w.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	w.Lock()
+	w.current--
+	w.Unlock()
+}
+
+func TestTreeSupervisor_ShutdownCancelsContextBeforeWaiting(t *testing.T) {
+	logger := bufferedLogger()
+	parent, cancelParent := context.WithCancel(context.Background())
+	defer cancelParent()
+
+	s := NewTreeSupervisor(parent, "root")
+	ctx := s.Context()
+	s.Supervise(Forever(ctx, "foo", logger, func() {
+		<-ctx.Done()
+	}))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	report := s.Shutdown(shutdownCtx)
+
+	require.True(t, report.Clean())
+	require.NoError(t, parent.Err(), "parent context shouldn't be cancelled by Shutdown")
+	require.Empty(t, logger.errors)
+}
+
+func TestTreeSupervisor_ZeroValueOwnsContext(t *testing.T) {
+	s := TreeSupervisor{}
+	require.NoError(t, s.Context().Err())
+	s.Shutdown(context.Background())
+	require.Error(t, s.Context().Err())
+}
+
+func TestTreeSupervisor_StartsGoroutinesAlreadySupervised(t *testing.T) {
+	logger := bufferedLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := NewTreeSupervisor(ctx, "root")
+
+	for i := 0; i < 100; i++ {
+		s.Forever("forever", logger, func() {})
+		s.ForeverCtx("forever ctx", logger, func(ctx context.Context) error { return nil })
+		s.Go("go", logger, func() {})
+	}
+
+	report := s.Shutdown(context.Background())
+	require.Len(t, report.Children, 300)
+	require.True(t, report.Clean())
+	require.Empty(t, logger.errors, "goroutines terminated before being supervised")
+}
+
+func TestTreeSupervisor_ForeverInheritsRestartIntensityBeforeFirstRun(t *testing.T) {
+	logger := bufferedLogger()
+	s := &TreeSupervisor{RestartIntensity: RestartIntensity{MaxRestarts: 0, Period: time.Hour}}
+
+	h := s.Forever("crashing", logger, func() {
+		panic("foo")
+	})
+	<-h.Done()
+
+	require.True(t, errors.Is(h.Err(), ErrCrashLoop), "didn't give up on the first panic: %v", h.Err())
+	s.Shutdown(context.Background())
+}
+
+func TestTreeSupervisor_NewChildCanBeShutDownIndependently(t *testing.T) {
+	logger := bufferedLogger()
+	node := NewTreeSupervisor(context.Background(), "node")
+	consensus := node.NewChild("consensus")
+	blockSync := consensus.NewChild("sync")
+
+	votes := consensus.Forever("votes", logger, func() {
+		<-consensus.Context().Done()
+	})
+	labels := make(chan string, 1)
+	blocks := blockSync.ForeverCtx("blocks", logger, func(ctx context.Context) error {
+		parent, _ := pprof.Label(ctx, ParentLabel)
+		labels <- parent
+		<-ctx.Done()
+		return nil
+	})
+	require.Equal(t, "node/consensus/sync", <-labels)
+
+	var names []string
+	for _, info := range node.Snapshot() {
+		names = append(names, info.Name)
+	}
+	require.Equal(t, []string{"node/consensus/sync/blocks", "node/consensus/votes"}, names)
+
+	report := blockSync.Shutdown(context.Background())
+	require.True(t, report.Clean())
+	require.Equal(t, "node/consensus/sync/blocks", report.Children[0].Name)
+	<-blocks.Done()
+	require.Error(t, blockSync.Context().Err())
+	require.NoError(t, consensus.Context().Err(), "shutting down a child shouldn't shut down its parent")
+	select {
+	case <-votes.Done():
+		t.Fatal("sibling of the child stopped")
+	default:
+	}
+
+	names = nil
+	for _, info := range node.Snapshot() {
+		names = append(names, info.Name)
+	}
+	require.Equal(t, []string{"node/consensus/votes"}, names, "a child shut down on its own should be removed from its parent")
+
+	report = node.Shutdown(context.Background())
+	require.True(t, report.Clean())
+	require.Len(t, report.Children, 1)
+	require.Equal(t, "node/consensus/votes", report.Children[0].Name)
+	<-votes.Done()
+	require.Empty(t, logger.errors)
+}
@@ -0,0 +1,473 @@
+package govnr
+
+import (
+	"context"
+	"runtime/trace"
+	"sync"
+	"time"
+)
+
+// Determines which children a StrategySupervisor restarts when one of them exits, following Erlang/OTP supervisors
+type RestartStrategy int
+
+const (
+	// Only the child that exited is restarted
+	OneForOne RestartStrategy = iota
+	// All children are stopped, in reverse start order, and then restarted in start order
+	OneForAll
+	// The child that exited and all children started after it are stopped, in reverse start order, and then restarted in start order
+	RestForOne
+)
+
+func (s RestartStrategy) String() string {
+	switch s {
+	case OneForOne:
+		return "one_for_one"
+	case OneForAll:
+		return "one_for_all"
+	case RestForOne:
+		return "rest_for_one"
+	}
+	return "unknown"
+}
+
+// Describes a child of a StrategySupervisor
+type ChildSpec struct {
+	Name string
+	// Runs the child until ctx is closed. Called again every time the child is restarted, so it must start from scratch on each call.
+	// A panic or an error are reported to the supervisor's Errorer as for ForeverCtx.
+	Start func(ctx context.Context) error
+	// Whether the child is restarted when it exits. A child that isn't restarted doesn't cause its siblings to be restarted either,
+	// and once finished it is no longer started when its siblings are restarted
+	Restart RestartType
+	// How long to wait for the child to exit once its Context is closed, before giving up on it and emitting a *ShutdownTimeoutError;
+	// DefaultChildShutdown if zero
+	Shutdown time.Duration
+}
+
+// How long a StrategySupervisor waits for a child to exit when stopping it, unless the ChildSpec sets Shutdown
+const DefaultChildShutdown = 5 * time.Second
+
+// A supervisor that starts children from ChildSpecs and restarts them, according to its RestartStrategy, whenever one exits.
+// Unlike Forever, where each goroutine restarts only itself, this allows tightly coupled children, such as a producer and its consumer,
+// to be restarted together in a defined order.
+//
+// Restarts of each child are paced by the RestartPolicy and BusyRestartPolicy, as they are for Forever. Restarts across all children
+// are limited by the RestartIntensity, set WithRestartIntensity or inherited from a TreeSupervisor; once exceeded, all children are stopped,
+// a *CrashLoopError is emitted and Err() returns it. The Metrics and Observer of a supervising TreeSupervisor observe every child.
+// WithRestartType is ignored, since every ChildSpec has its own RestartType.
+//
+// A StrategySupervisor is a ShutdownWaiter, so it can itself be supervised by a TreeSupervisor.
+type StrategySupervisor struct {
+	name         string
+	errorHandler Errorer
+	strategy     RestartStrategy
+	ownIntensity bool
+	policy       RestartPolicy
+	busyRestart  BusyRestartPolicy
+	observers    observers
+	creationSite CreationSiteCapture
+	createdBy    []Frame
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	exits  chan childExit
+	closed chan struct{}
+
+	restarting sync.Mutex // serializes starting and restarting children
+	state      struct {
+		sync.Mutex
+		children  []*strategyChild
+		err       error
+		intensity RestartIntensity
+		inherited observers // passed on to every child, see inheritObservers
+	}
+}
+
+type strategyChild struct {
+	id         uint64
+	spec       ChildSpec
+	generation int
+	cancel     context.CancelFunc
+	done       chan struct{}
+	finished   bool
+	createdBy  []Frame
+	observers  goroutineObservers
+
+	stats struct {
+		sync.Mutex
+		firstStarted time.Time
+		starts       int
+		state        GoroutineState
+		lastErr      error
+		backoff      backoffState
+		busy         busyDetector
+		delay        time.Duration // before the latest restart
+	}
+}
+
+type childExit struct {
+	child      *strategyChild
+	generation int
+	err        error
+}
+
+// Creates a StrategySupervisor whose children run until ctx is closed, or until Shutdown is called
+func NewStrategySupervisor(ctx context.Context, name string, errorHandler Errorer, strategy RestartStrategy, opts ...Option) *StrategySupervisor {
+	o := newOptions(opts)
+	s := &StrategySupervisor{
+		name:         name,
+		errorHandler: errorHandler,
+		strategy:     strategy,
+		policy:       o.restartPolicy,
+		busyRestart:  o.busyRestart,
+		exits:        make(chan childExit),
+		closed:       make(chan struct{}),
+		observers:    o.observers,
+		creationSite: o.creationSite,
+		createdBy:    captureCreationSite(o.creationSite, 1),
+	}
+	if o.restartIntensity != nil {
+		s.state.intensity = *o.restartIntensity
+		s.ownIntensity = true
+	}
+	s.ctx, s.cancel = context.WithCancel(supervisorContext(ctx, name))
+	go withLabels(ctx, 0, name, func(context.Context) {
+		s.manage()
+	})
+	return s
+}
+
+// Starts a new child, after all previously started children. Does nothing if the supervisor is shutting down
+func (s *StrategySupervisor) StartChild(spec ChildSpec) {
+	s.restarting.Lock()
+	defer s.restarting.Unlock()
+	if s.ctx.Err() != nil {
+		return
+	}
+	c := &strategyChild{id: nextGoroutineID(), spec: spec, createdBy: captureCreationSite(s.creationSite, 1), observers: goroutineObservers{own: s.observers, all: s.observers}}
+	c.stats.backoff.policy = s.policy
+	c.stats.busy.policy = s.busyRestart
+	s.state.Lock()
+	c.observers.inherit(s.state.inherited)
+	s.state.children = append(s.state.children, c)
+	s.state.Unlock()
+	s.start(c)
+}
+
+func (s *StrategySupervisor) Name() string {
+	return s.name
+}
+
+// limits restarts across all children, unless the StrategySupervisor was created WithRestartIntensity
+func (s *StrategySupervisor) inheritRestartIntensity(i RestartIntensity) {
+	if s.ownIntensity {
+		return
+	}
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.intensity = i
+}
+
+// passes o on to every child, including those started later
+func (s *StrategySupervisor) inheritObservers(o observers) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	s.state.inherited = s.state.inherited.with(o)
+	for _, c := range s.state.children {
+		c.observers.inherit(o)
+	}
+}
+
+func (s *StrategySupervisor) restartIntensity() RestartIntensity {
+	s.state.Lock()
+	defer s.state.Unlock()
+	return s.state.intensity
+}
+
+// returns the observers the StrategySupervisor was created with that aren't also inherited, as goroutineObservers.ownOnly does
+func (s *StrategySupervisor) ownObservers() observers {
+	s.state.Lock()
+	defer s.state.Unlock()
+	var o observers
+	for _, observer := range s.observers {
+		if !s.state.inherited.contains(observer) {
+			o = append(o, observer)
+		}
+	}
+	return o
+}
+
+// Returns the *CrashLoopError emitted if the children exceeded the RestartIntensity, nil otherwise
+func (s *StrategySupervisor) Err() error {
+	s.state.Lock()
+	defer s.state.Unlock()
+	return s.state.err
+}
+
+// Stops all children and waits for them to exit, as WaitUntilShutdownWithReport does
+func (s *StrategySupervisor) Shutdown(shutdownContext context.Context) *ShutdownReport {
+	s.cancel()
+	return s.WaitUntilShutdownWithReport(shutdownContext)
+}
+
+func (s *StrategySupervisor) WaitUntilShutdown(shutdownContext context.Context) {
+	s.WaitUntilShutdownWithReport(shutdownContext)
+}
+
+// Returns a ShutdownReport with an entry for every child; since children are stopped together, they all share the same Duration
+func (s *StrategySupervisor) WaitUntilShutdownWithReport(shutdownContext context.Context) *ShutdownReport {
+	return s.ownObservers().shutdown(0, s.name, func() *ShutdownReport {
+		return s.waitWithReport(shutdownContext)
+	})
+}
+
+func (s *StrategySupervisor) waitWithReport(shutdownContext context.Context) *ShutdownReport {
+	waitStarted := time.Now()
+	timedOut := !waitUntilClosed(s.closed, shutdownContext)
+	if timedOut && shutdownContext.Err() == context.DeadlineExceeded {
+		var ids []uint64
+		for _, c := range s.children() {
+			ids = append(ids, c.id)
+		}
+		s.errorHandler.Error(&ShutdownTimeoutError{Name: s.name, Waited: time.Since(waitStarted), Cause: shutdownContext.Err(), Stacks: goroutineStacksByID(shutdownContext, ids...), CreatedBy: s.createdBy, kind: kindStrategySupervisor})
+	}
+
+	report := &ShutdownReport{}
+	for _, c := range s.children() {
+		report.Children = append(report.Children, ChildShutdown{Name: c.spec.Name, Duration: time.Since(waitStarted), TimedOut: timedOut, Err: c.lastErr()})
+	}
+	return report.prefixed(s.name)
+}
+
+// Lists every child, with names prefixed by the supervisor's name
+func (s *StrategySupervisor) Snapshot() []GoroutineInfo {
+	infos, _ := s.introspect()
+	return prefixSnapshot(s.name, infos)
+}
+
+func (s *StrategySupervisor) supervisorName() string {
+	return s.name
+}
+
+func (s *StrategySupervisor) introspect() ([]GoroutineInfo, []Introspector) {
+	var infos []GoroutineInfo
+	for _, c := range s.children() {
+		infos = append(infos, c.snapshot())
+	}
+	return infos, nil
+}
+
+func (s *StrategySupervisor) children() []*strategyChild {
+	s.state.Lock()
+	defer s.state.Unlock()
+	return append([]*strategyChild(nil), s.state.children...)
+}
+
+// must be called with s.restarting held
+func (s *StrategySupervisor) start(c *strategyChild) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	c.generation++
+	c.cancel = cancel
+	c.done = make(chan struct{})
+
+	exit := childExit{child: c, generation: c.generation}
+	done := c.done
+	attempt, delay := c.started()
+	name := joinName(s.name, c.spec.Name)
+	if attempt > 1 {
+		c.observers.observers().restart(c.id, name, attempt, delay)
+		traceLogf(ctx, "govnr.restart", "%s attempt %d", name, attempt)
+	}
+	go withLabels(ctx, c.id, c.spec.Name, func(ctx context.Context) {
+		started := time.Now()
+		exit.err = c.observers.run(c.id, name, attempt, func() error {
+			defer trace.StartRegion(ctx, name).End()
+			return tryOnceCtx(ctx, s.errorHandler, kindStrategySupervisor, name, c.createdBy, attempt, c.spec.Start)
+		})
+		if exit.err != nil {
+			traceFailed(ctx, attempt, exit.err)
+		}
+		delay, busyRuns := c.exited(attempt, exit.err, time.Since(started))
+		if busyRuns > 0 {
+			s.errorHandler.Error(&BusyRestartError{Name: name, Runs: busyRuns, Threshold: s.busyRestart.Threshold, CreatedBy: c.createdBy, kind: kindStrategySupervisor})
+		}
+		sleepUnlessDone(ctx, delay) // the exit is handled, and the child restarted, only after the delay
+		close(done)
+		select {
+		case s.exits <- exit:
+		case <-s.closed:
+		}
+	})
+}
+
+// closes the Context of c and waits for it to exit, giving up after its Shutdown timeout; must be called with s.restarting held.
+// Children stopped together should share a stopContext made withSharedProfile, so that the goroutine profile is taken only once
+func (s *StrategySupervisor) stop(stopContext context.Context, c *strategyChild) {
+	c.cancel()
+	timeout := c.spec.Shutdown
+	if timeout <= 0 {
+		timeout = DefaultChildShutdown
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-c.done:
+	case <-timer.C:
+		s.errorHandler.Error(&ShutdownTimeoutError{Name: joinName(s.name, c.spec.Name), Waited: timeout, Cause: context.DeadlineExceeded, Stacks: goroutineStacksByID(stopContext, c.id), CreatedBy: c.createdBy, kind: kindStrategySupervisor})
+	}
+}
+
+func (s *StrategySupervisor) manage() {
+	window := &restartWindow{}
+	for {
+		select {
+		case exit := <-s.exits:
+			s.handleExit(exit, window)
+		case <-s.ctx.Done():
+			s.restarting.Lock()
+			children := s.children()
+			stopContext := withSharedProfile(context.Background())
+			for i := len(children) - 1; i >= 0; i-- {
+				s.stop(stopContext, children[i])
+				if !children[i].finished {
+					s.finish(children[i], nil)
+				}
+			}
+			close(s.closed)
+			s.restarting.Unlock()
+			return
+		}
+	}
+}
+
+func (s *StrategySupervisor) handleExit(exit childExit, window *restartWindow) {
+	s.restarting.Lock()
+	defer s.restarting.Unlock()
+	if s.ctx.Err() != nil || exit.generation != exit.child.generation { // shutting down, or the child was already restarted along with a sibling
+		return
+	}
+	if !exit.child.spec.Restart.restarts(exit.err) {
+		s.finish(exit.child, exit.err)
+		return
+	}
+
+	if window.exceeded(s.restartIntensity(), time.Now()) {
+		s.escalate()
+		return
+	}
+	exit.child.restarting()
+
+	children := s.children()
+	switch s.strategy {
+	case OneForAll:
+		s.restartFrom(children, 0)
+	case RestForOne:
+		for i, c := range children {
+			if c == exit.child {
+				s.restartFrom(children, i)
+				break
+			}
+		}
+	default:
+		s.start(exit.child)
+	}
+}
+
+// must be called with s.restarting held
+func (s *StrategySupervisor) restartFrom(children []*strategyChild, first int) {
+	stopContext := withSharedProfile(context.Background())
+	for i := len(children) - 1; i >= first; i-- {
+		s.stop(stopContext, children[i])
+	}
+	for _, c := range children[first:] {
+		if c.spec.Restart == Temporary && !c.finished {
+			s.finish(c, nil)
+		}
+		if !c.finished {
+			s.start(c)
+		}
+	}
+}
+
+// marks c as no longer running, never to be restarted; must be called with s.restarting held
+func (s *StrategySupervisor) finish(c *strategyChild, err error) {
+	c.finished = true
+	c.observers.observers().terminate(c.id, joinName(s.name, c.spec.Name), err)
+}
+
+func (s *StrategySupervisor) escalate() {
+	intensity := s.restartIntensity()
+	err := &CrashLoopError{Name: s.name, MaxRestarts: intensity.MaxRestarts, Period: intensity.Period, Time: time.Now(), CreatedBy: s.createdBy, kind: kindStrategySupervisor}
+	s.state.Lock()
+	s.state.err = err
+	s.state.Unlock()
+	s.errorHandler.Error(err)
+	s.cancel()
+	if intensity.Escalate != nil {
+		intensity.Escalate()
+	}
+}
+
+// returns the number of the run about to start, and how long the child waited before it
+func (c *strategyChild) started() (attempt int, delay time.Duration) {
+	c.stats.Lock()
+	defer c.stats.Unlock()
+	if c.stats.starts == 0 {
+		c.stats.firstStarted = time.Now()
+	}
+	c.stats.starts++
+	c.stats.state = Running
+	return c.stats.starts, c.stats.delay
+}
+
+// records the exit of run number attempt after running for ran, returning how long to wait before restarting it
+// according to the RestartPolicy and BusyRestartPolicy, and the length of the busy streak if it should be reported.
+// Ignores runs abandoned after their shutdown timeout, which exit after the child was restarted
+func (c *strategyChild) exited(attempt int, err error, ran time.Duration) (delay time.Duration, busyRuns int) {
+	c.stats.Lock()
+	defer c.stats.Unlock()
+	if attempt != c.stats.starts {
+		return 0, 0
+	}
+	c.stats.state = Stopped
+	if err != nil {
+		c.stats.lastErr = err
+	}
+	c.stats.backoff.ran(ran)
+	if !c.spec.Restart.restarts(err) {
+		return 0, 0
+	}
+	if err == nil {
+		var report bool
+		if delay, report = c.stats.busy.returned(ran); report {
+			busyRuns = c.stats.busy.streak
+		}
+	} else {
+		c.stats.busy.reset()
+		delay = c.stats.backoff.next()
+	}
+	if delay > 0 {
+		c.stats.state = BackingOff
+	}
+	c.stats.delay = delay
+	return delay, busyRuns
+}
+
+func (c *strategyChild) restarting() {
+	c.stats.Lock()
+	defer c.stats.Unlock()
+	c.stats.state = Restarting
+}
+
+func (c *strategyChild) snapshot() GoroutineInfo {
+	c.stats.Lock()
+	defer c.stats.Unlock()
+	return GoroutineInfo{Name: c.spec.Name, State: c.stats.state, Started: c.stats.firstStarted, Restarts: c.stats.starts - 1, LastErr: c.stats.lastErr, CreatedBy: c.createdBy}
+}
+
+func (c *strategyChild) lastErr() error {
+	c.stats.Lock()
+	defer c.stats.Unlock()
+	return c.stats.lastErr
+}
@@ -0,0 +1,189 @@
+package govnr
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+type startCounter struct {
+	sync.Mutex
+	starts  map[string]int
+	started chan string
+}
+
+func newStartCounter() *startCounter {
+	return &startCounter{starts: make(map[string]int), started: make(chan string, 100)}
+}
+
+func (c *startCounter) count(name string) int {
+	c.Lock()
+	defer c.Unlock()
+	return c.starts[name]
+}
+
+// returns a ChildSpec that fails on its first run if failOnce is set, and otherwise runs until its context is closed
+func (c *startCounter) spec(name string, failOnce bool) ChildSpec {
+	return ChildSpec{Name: name, Start: func(ctx context.Context) error {
+		c.Lock()
+		c.starts[name]++
+		first := c.starts[name] == 1
+		c.Unlock()
+		c.started <- name
+		if failOnce && first {
+			return errors.New("foo")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+}
+
+// waits for n runs of the children returned by spec to start
+func (c *startCounter) awaitStarts(t *testing.T, n int) {
+	for i := 0; i < n; i++ {
+		select {
+		case <-c.started:
+		case <-time.After(time.Second):
+			t.Fatalf("only %d of %d runs started", i, n)
+		}
+	}
+}
+
+func TestStrategySupervisor_RestartsAccordingToStrategy(t *testing.T) {
+	for strategy, expectedStarts := range map[RestartStrategy][]int{
+		OneForOne:  {1, 2, 1},
+		OneForAll:  {2, 2, 2},
+		RestForOne: {1, 2, 2},
+	} {
+		t.Run(strategy.String(), func(t *testing.T) {
+			logger := bufferedLogger()
+			counter := newStartCounter()
+			s := NewStrategySupervisor(context.Background(), "sup", logger, strategy)
+
+			s.StartChild(counter.spec("a", false))
+			release := make(chan struct{})
+			s.StartChild(ChildSpec{Name: "b", Start: func(ctx context.Context) error {
+				<-release
+				return counter.spec("b", true).Start(ctx)
+			}})
+			s.StartChild(counter.spec("c", false))
+			close(release)
+
+			counter.awaitStarts(t, expectedStarts[0]+expectedStarts[1]+expectedStarts[2])
+			require.Equal(t, expectedStarts, []int{counter.count("a"), counter.count("b"), counter.count("c")})
+
+			report := s.Shutdown(context.Background())
+			require.True(t, report.Clean())
+			require.Equal(t, "sup/b", report.Children[1].Name)
+			require.IsType(t, &RunError{}, report.Children[1].Err)
+			require.Contains(t, report.Children[1].Err.Error(), "StrategySupervisor governed goroutine sup/b failed on run 1")
+			require.Len(t, logger.errors, 1, "expected only the error returned by b")
+		})
+	}
+}
+
+func TestStrategySupervisor_EscalatesWhenRestartIntensityExceeded(t *testing.T) {
+	logger := bufferedLogger()
+	s := NewStrategySupervisor(context.Background(), "sup", logger, OneForOne, WithRestartIntensity(RestartIntensity{MaxRestarts: 2, Period: time.Minute}))
+	s.StartChild(ChildSpec{Name: "crashing", Start: func(ctx context.Context) error {
+		panic("foo")
+	}})
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s.WaitUntilShutdown(shutdownCtx)
+
+	require.True(t, errors.Is(s.Err(), ErrCrashLoop))
+	require.Len(t, logger.errors, 4, "expected 3 panics and a crash loop error")
+}
+
+func TestStrategySupervisor_ThrottlesChildThatKeepsReturningRightAway(t *testing.T) {
+	logger := bufferedLogger()
+	counter := newStartCounter()
+	s := NewStrategySupervisor(context.Background(), "sup", logger, OneForOne,
+		WithBusyRestartPolicy(BusyRestartPolicy{Threshold: time.Millisecond, Streak: 5, Throttle: ConstantBackoff(time.Hour)}))
+	s.StartChild(ChildSpec{Name: "busy", Restart: Permanent, Start: func(ctx context.Context) error {
+		counter.Lock()
+		counter.starts["busy"]++
+		counter.Unlock()
+		return nil
+	}})
+
+	report := <-logger.errors
+	require.True(t, errors.Is(report.err, ErrBusyRestart), "not a busy restart: %v", report.err)
+	require.Contains(t, report.err.Error(), "StrategySupervisor governed goroutine sup/busy")
+	runs := counter.count("busy")
+	s.Shutdown(context.Background())
+
+	require.Equal(t, 5, runs)
+	require.Equal(t, runs, counter.count("busy"), "restarted while throttled")
+}
+
+func TestStrategySupervisor_PacesRestartsWithRestartPolicy(t *testing.T) {
+	logger := bufferedLogger()
+	counter := newStartCounter()
+	s := NewStrategySupervisor(context.Background(), "sup", logger, OneForOne,
+		WithRestartPolicy(RestartPolicy{Backoff: ConstantBackoff(time.Hour)}))
+	failing := counter.spec("failing", true)
+	s.StartChild(failing)
+
+	<-logger.errors
+	deadline := time.Now().Add(time.Second)
+	for s.Snapshot()[0].State != BackingOff && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	require.Equal(t, BackingOff, s.Snapshot()[0].State)
+	require.Equal(t, 1, counter.count("failing"))
+
+	require.True(t, s.Shutdown(context.Background()).Clean())
+}
+
+func TestStrategySupervisor_GivesUpOnChildThatIgnoresItsContext(t *testing.T) {
+	logger := bufferedLogger()
+	counter := newStartCounter()
+	release := make(chan struct{})
+	defer close(release)
+	s := NewStrategySupervisor(context.Background(), "sup", logger, OneForAll)
+	s.StartChild(ChildSpec{Name: "stuck", Shutdown: 10 * time.Millisecond, Start: func(ctx context.Context) error {
+		<-release
+		return nil
+	}})
+	s.StartChild(counter.spec("failing", true))
+
+	<-logger.errors // failing returned an error
+	report := <-logger.errors
+	require.True(t, errors.Is(report.err, ErrShutdownTimeout), "not a shutdown timeout: %v", report.err)
+	var timeoutErr *ShutdownTimeoutError
+	require.True(t, errors.As(report.err, &timeoutErr))
+	require.Equal(t, "sup/stuck", timeoutErr.Name)
+
+	counter.awaitStarts(t, 2)
+	require.Equal(t, 2, counter.count("failing"), "siblings weren't restarted")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s.Shutdown(shutdownCtx)
+	require.NoError(t, shutdownCtx.Err(), "shutdown was blocked by the stuck child")
+}
+
+func TestStrategySupervisor_InheritsRestartIntensityAndObserversFromTreeSupervisor(t *testing.T) {
+	logger := bufferedLogger()
+	observer := &recordingObserver{}
+	node := &TreeSupervisor{Name: "node", RestartIntensity: RestartIntensity{MaxRestarts: 0, Period: time.Hour}, Observer: observer}
+	s := NewStrategySupervisor(node.Context(), "sup", logger, OneForOne)
+	node.Supervise(s)
+	s.StartChild(ChildSpec{Name: "crashing", Start: func(ctx context.Context) error {
+		panic("foo")
+	}})
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s.WaitUntilShutdown(shutdownCtx)
+
+	require.True(t, errors.Is(s.Err(), ErrCrashLoop), "didn't give up on the first panic: %v", s.Err())
+	require.Equal(t, []string{"start sup/crashing 1", "panic sup/crashing 1", "exit sup/crashing 1", "terminate sup/crashing 0"}, observer.recorded())
+	node.Shutdown(context.Background())
+}
@@ -0,0 +1,74 @@
+package govnr
+
+import "context"
+
+// A single child of a TreeSupervisor, as returned by SuperviseChild, that can be stopped and removed from the tree on its own,
+// e.g. a goroutine serving a single peer connection, while the rest of the tree keeps running.
+// All methods are safe to call concurrently with each other and with the TreeSupervisor's WaitUntilShutdown.
+type SupervisedChild struct {
+	parent *TreeSupervisor
+	w      ShutdownWaiter
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// passes the RestartIntensity of a TreeSupervisor to the goroutines started for a SupervisedChild, and marks them as supervised,
+// without adding them to the TreeSupervisor's children; SuperviseChild adds the ShutdownWaiter returned by start instead
+type adoptingSupervisor struct {
+	t *TreeSupervisor
+}
+
+func (s adoptingSupervisor) Supervise(w ShutdownWaiter) {
+	s.t.adopt(w)
+}
+
+// Calls start with a Context of its own, derived from t's Context, and supervises the returned ShutdownWaiter, which should stop
+// when that Context closes. Returns a SupervisedChild through which it can be cancelled, waited on and removed from t.
+//
+// Goroutines that start should be started with the Context it receives, through SupervisedForever, SupervisedForeverCtx or SupervisedGo
+// so that they are supervised before they first run, or through Forever, ForeverCtx or Go; not through the methods of t,
+// which would supervise them a second time.
+func (t *TreeSupervisor) SuperviseChild(start func(ctx context.Context) ShutdownWaiter) *SupervisedChild {
+	ctx, cancel := context.WithCancel(WithSupervisor(t.Context(), adoptingSupervisor{t}))
+	c := &SupervisedChild{parent: t, ctx: ctx, cancel: cancel}
+	c.w = start(ctx)
+	t.adopt(c.w)
+	t.add(c)
+	return c
+}
+
+// Returns the Context passed to start, which is closed by Cancel or when the TreeSupervisor shuts down
+func (c *SupervisedChild) Context() context.Context {
+	return c.ctx
+}
+
+// Closes the Context of the child, without waiting for it to stop
+func (c *SupervisedChild) Cancel() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+func (c *SupervisedChild) WaitUntilShutdown(shutdownContext context.Context) {
+	c.WaitUntilShutdownWithReport(shutdownContext)
+}
+
+// Waits for the child to stop, until shutdownContext closes, returning a ShutdownReport named after the full path of the TreeSupervisor,
+// e.g. node/consensus/peer 1
+func (c *SupervisedChild) WaitUntilShutdownWithReport(shutdownContext context.Context) *ShutdownReport {
+	return waitWithReport(c.w, shutdownContext).prefixed(c.parent.path())
+}
+
+// Removes the child from the TreeSupervisor, which no longer waits for it or lists it in its Snapshot. Doesn't stop the child;
+// once removed, the child is no longer supervised by anything, though it won't be reported as an *UnsupervisedTerminationError
+func (c *SupervisedChild) Remove() {
+	c.parent.remove(c)
+}
+
+// Cancels the child, waits for it to stop until shutdownContext closes, then removes it from the TreeSupervisor
+func (c *SupervisedChild) Stop(shutdownContext context.Context) *ShutdownReport {
+	c.Cancel()
+	report := c.WaitUntilShutdownWithReport(shutdownContext)
+	c.Remove()
+	return report
+}
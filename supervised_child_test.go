@@ -0,0 +1,86 @@
+package govnr
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func startPeer(name string, logger Errorer) func(ctx context.Context) ShutdownWaiter {
+	return func(ctx context.Context) ShutdownWaiter {
+		return SupervisedForever(ctx, name, logger, func() {
+			<-ctx.Done()
+		})
+	}
+}
+
+func TestSupervisedChild_StopsAndRemovesSingleChild(t *testing.T) {
+	logger := bufferedLogger()
+	node := NewTreeSupervisor(context.Background(), "node")
+	peer1 := node.SuperviseChild(startPeer("peer 1", logger))
+	peer2 := node.SuperviseChild(startPeer("peer 2", logger))
+
+	report := peer1.Stop(context.Background())
+	require.True(t, report.Clean())
+	require.Equal(t, "node/peer 1", report.Children[0].Name)
+	require.Error(t, peer1.Context().Err())
+	require.NoError(t, peer2.Context().Err(), "stopping a child shouldn't stop its siblings")
+
+	infos := node.Snapshot()
+	require.Len(t, infos, 1)
+	require.Equal(t, "node/peer 2", infos[0].Name)
+	require.Equal(t, Running, infos[0].State)
+
+	report = node.Shutdown(context.Background())
+	require.Len(t, report.Children, 1)
+	require.Equal(t, "node/peer 2", report.Children[0].Name)
+	require.Empty(t, logger.errors)
+}
+
+func TestSupervisedChild_InheritsRestartIntensityAndIsSupervisedBeforeFirstRun(t *testing.T) {
+	logger := bufferedLogger()
+	node := &TreeSupervisor{Name: "node", RestartIntensity: RestartIntensity{MaxRestarts: 0, Period: time.Hour}}
+
+	var h *ForeverHandle
+	node.SuperviseChild(func(ctx context.Context) ShutdownWaiter {
+		h = SupervisedForever(ctx, "crashing", logger, func() {
+			panic("foo")
+		})
+		return h
+	})
+	<-h.Done()
+
+	require.True(t, errors.Is(h.Err(), ErrCrashLoop), "didn't give up on the first panic: %v", h.Err())
+	node.Shutdown(context.Background())
+	<-logger.errors // the panic
+	<-logger.errors // the crash loop
+	require.Empty(t, logger.errors, "the child was reported as unsupervised")
+}
+
+func TestSupervisedChild_StopIsSafeConcurrentlyWithShutdown(t *testing.T) {
+	logger := bufferedLogger()
+	node := NewTreeSupervisor(context.Background(), "node")
+	var children []*SupervisedChild
+	for i := 0; i < 50; i++ {
+		children = append(children, node.SuperviseChild(startPeer(fmt.Sprintf("peer %d", i), logger)))
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range children {
+		wg.Add(1)
+		go func(c *SupervisedChild) {
+			defer wg.Done()
+			c.Stop(context.Background())
+		}(c)
+	}
+	report := node.Shutdown(context.Background())
+	wg.Wait()
+
+	require.True(t, report.Clean())
+	require.Empty(t, node.Snapshot())
+	require.Empty(t, logger.errors)
+}
@@ -0,0 +1,34 @@
+package govnr
+
+import (
+	"context"
+	"fmt"
+	"runtime/trace"
+)
+
+// Starts a runtime/trace task of taskType for the governed goroutine or supervisor named name, returning a ctx carrying the task
+// and a function that ends it. Does nothing unless tracing is enabled, so that governed goroutines cost nothing extra when it isn't
+func traceTask(ctx context.Context, taskType string, name string) (context.Context, func()) {
+	if !trace.IsEnabled() {
+		return ctx, func() {}
+	}
+	ctx, task := trace.NewTask(ctx, taskType)
+	trace.Log(ctx, "govnr.name", name)
+	return ctx, task.End
+}
+
+// logs to the task carried by ctx, formatting the message only if tracing is enabled
+func traceLogf(ctx context.Context, category string, format string, args ...interface{}) {
+	if trace.IsEnabled() {
+		trace.Log(ctx, category, fmt.Sprintf(format, args...))
+	}
+}
+
+// logs the error reported for a failed run of a governed function, under "govnr.panic" if it panicked
+func traceFailed(ctx context.Context, attempt int, err error) {
+	category := "govnr.error"
+	if _, panicked := err.(*PanicError); panicked {
+		category = "govnr.panic"
+	}
+	traceLogf(ctx, category, "attempt %d: %v", attempt, err)
+}
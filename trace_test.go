@@ -0,0 +1,51 @@
+package govnr
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/require"
+	"runtime/trace"
+	"testing"
+	"time"
+)
+
+func TestForever_TracesLifetimeRunsAndRestartsWhenTracingIsEnabled(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, trace.Start(&buf))
+
+	logger := bufferedLogger()
+	s := NewTreeSupervisor(context.Background(), "node")
+	ctx := s.Context()
+	runs := 0
+	restarted := make(chan struct{})
+	s.Supervise(Forever(ctx, "traced-goroutine", logger, func() {
+		runs++
+		if runs == 1 {
+			panic("foo")
+		}
+		close(restarted)
+		<-ctx.Done()
+	}))
+	stuck, releaseStuck := context.WithCancel(context.Background())
+	defer releaseStuck()
+	s.Supervise(Forever(stuck, "traced-stuck", logger, func() {
+		<-stuck.Done()
+	}))
+	<-restarted
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	s.Shutdown(shutdownCtx)
+	trace.Stop()
+
+	for _, s := range []string{"govnr.Forever", "traced-goroutine", "govnr.panic", "govnr.restart", "govnr.Shutdown", "govnr.shutdown timeout", "node/traced-stuck"} {
+		require.Contains(t, buf.String(), s)
+	}
+}
+
+func TestTraceTask_DoesNothingWhenTracingIsDisabled(t *testing.T) {
+	ctx := context.Background()
+	tracedCtx, endTask := traceTask(ctx, "govnr.Forever", "foo")
+	endTask()
+	require.Equal(t, ctx, tracedCtx)
+}